@@ -0,0 +1,255 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"iss-telemetry-analyzer/src/dynamo"
+	"iss-telemetry-analyzer/src/types"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Query limits
+const (
+	defaultLimit  = 100
+	maxLimit      = 1000
+	defaultRange  = time.Hour
+	maxRange      = 31 * 24 * time.Hour
+	maxBucketScan = 720 // Bucket keys probed per /buckets page
+)
+
+// query holds the parsed parameters shared by every endpoint
+type query struct {
+	from   time.Time
+	to     time.Time
+	after  string
+	limit  int
+	format string
+}
+
+// Handler serves the read-only query API over API Gateway HTTP requests:
+//
+//	GET /scores     processed data of finalized buckets
+//	GET /buckets    raw telemetry buckets
+//	GET /stats      rolling anomaly score statistics
+//	GET /anomalies  buckets with raised or changed anomaly levels
+//
+// Time-range endpoints accept from, to (RFC3339), limit, cursor and
+// format (json or csv) query parameters.
+func Handler(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	if request.RequestContext.HTTP.Method != http.MethodGet {
+		return errorResponse(http.StatusMethodNotAllowed, "only GET is supported"), nil
+	}
+
+	q, err := parseQuery(request)
+
+	if err != nil {
+		return errorResponse(http.StatusBadRequest, err.Error()), nil
+	}
+
+	switch routePath(request) {
+	case "/scores":
+		processedData, cursor, err := dynamo.QueryProcessedData(q.from, q.to, q.after, q.limit)
+
+		if err != nil {
+			return internalError(err), nil
+		}
+
+		return listResponse(q.format, processedData, processedDataCSV(processedData), cursor)
+
+	case "/anomalies":
+		anomalyEvents, cursor, err := dynamo.QueryAnomalyEvents(q.from, q.to, q.after, q.limit)
+
+		if err != nil {
+			return internalError(err), nil
+		}
+
+		return listResponse(q.format, anomalyEvents, anomalyEventsCSV(anomalyEvents), cursor)
+
+	case "/buckets":
+		buckets, cursor, err := queryBuckets(q)
+
+		if err != nil {
+			return internalError(err), nil
+		}
+
+		return listResponse(q.format, buckets, bucketsCSV(buckets), cursor)
+
+	case "/stats":
+		stats, err := dynamo.GetScoreStats()
+
+		if err != nil {
+			return internalError(err), nil
+		}
+
+		if q.format == "csv" {
+			return csvResponse(statsCSV(stats), "")
+		}
+
+		return jsonResponse(stats)
+
+	default:
+		return errorResponse(http.StatusNotFound, "unknown endpoint"), nil
+	}
+}
+
+// routePath strips the stage prefix API Gateway adds for named stages
+func routePath(request events.APIGatewayV2HTTPRequest) string {
+	path := request.RawPath
+	stage := request.RequestContext.Stage
+
+	if stage != "" && stage != "$default" {
+		path = strings.TrimPrefix(path, "/"+stage)
+	}
+
+	return strings.TrimSuffix(path, "/")
+}
+
+func parseQuery(request events.APIGatewayV2HTTPRequest) (query, error) {
+	params := request.QueryStringParameters
+
+	q := query{
+		to:     time.Now().UTC(),
+		limit:  defaultLimit,
+		format: "json",
+	}
+
+	if value := params["to"]; value != "" {
+		to, err := time.Parse(time.RFC3339, value)
+
+		if err != nil {
+			return q, fmt.Errorf("invalid to: %v", err)
+		}
+
+		q.to = to.UTC()
+	}
+
+	q.from = q.to.Add(-defaultRange)
+
+	if value := params["from"]; value != "" {
+		from, err := time.Parse(time.RFC3339, value)
+
+		if err != nil {
+			return q, fmt.Errorf("invalid from: %v", err)
+		}
+
+		q.from = from.UTC()
+	}
+
+	if q.from.After(q.to) {
+		return q, fmt.Errorf("from must be before to")
+	}
+
+	if q.to.Sub(q.from) > maxRange {
+		return q, fmt.Errorf("time range must not exceed %d days", int(maxRange.Hours()/24))
+	}
+
+	if value := params["limit"]; value != "" {
+		limit, err := strconv.Atoi(value)
+
+		if err != nil || limit < 1 || limit > maxLimit {
+			return q, fmt.Errorf("limit must be between 1 and %d", maxLimit)
+		}
+
+		q.limit = limit
+	}
+
+	if value := params["cursor"]; value != "" {
+		after, err := base64.RawURLEncoding.DecodeString(value)
+
+		if err != nil {
+			return q, fmt.Errorf("invalid cursor")
+		}
+
+		if err := validateCursor(string(after), q.from, q.to); err != nil {
+			return q, err
+		}
+
+		q.after = string(after)
+	}
+
+	switch {
+	case params["format"] != "":
+		q.format = params["format"]
+	case strings.Contains(request.Headers["accept"], "text/csv"):
+		q.format = "csv"
+	}
+
+	if q.format != "json" && q.format != "csv" {
+		return q, fmt.Errorf("format must be json or csv")
+	}
+
+	return q, nil
+}
+
+// validateCursor checks that a decoded cursor is a key the API handed out: a
+// UTC RFC3339 timestamp within the queried range. Anything else would fail as
+// a storage error.
+func validateCursor(after string, from time.Time, to time.Time) error {
+	afterTime, err := time.Parse(time.RFC3339, after)
+
+	if err != nil || afterTime.UTC().Format(time.RFC3339) != after {
+		return fmt.Errorf("invalid cursor")
+	}
+
+	// Bucket pages start at the bucket holding from
+	if afterTime.Before(from.Truncate(dynamo.BucketDuration)) || afterTime.After(to) {
+		return fmt.Errorf("cursor is outside of the time range")
+	}
+
+	return nil
+}
+
+// queryBuckets probes the 5-second bucket keys of the range in order. The
+// cursor is the last bucket key probed.
+func queryBuckets(q query) ([]types.DynamoData, string, error) {
+	next := q.from.Truncate(dynamo.BucketDuration)
+
+	if q.after != "" {
+		after, err := time.Parse(time.RFC3339, q.after)
+
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid cursor: %v", err)
+		}
+
+		next = after.Add(dynamo.BucketDuration)
+	}
+
+	var buckets []types.DynamoData
+	var lastProbed string
+
+	for probed := 0; probed < maxBucketScan && len(buckets) < q.limit && !next.After(q.to); {
+		var keys []string
+
+		// BatchGetItem accepts up to 100 keys, and one page returns at most limit buckets
+		for len(keys) < min(100, q.limit-len(buckets)) && probed < maxBucketScan && !next.After(q.to) {
+			keys = append(keys, dynamo.BucketKey(next))
+			next = next.Add(dynamo.BucketDuration)
+			probed++
+		}
+
+		page, err := dynamo.GetBuckets(keys)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		// BatchGetItem does not preserve key order
+		sortBuckets(page)
+
+		buckets = append(buckets, page...)
+		lastProbed = keys[len(keys)-1]
+	}
+
+	if next.After(q.to) {
+		return buckets, "", nil
+	}
+
+	return buckets, lastProbed, nil
+}
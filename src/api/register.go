@@ -0,0 +1,21 @@
+package api
+
+import (
+	"encoding/json"
+	"iss-telemetry-analyzer/src/router"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func init() {
+	router.Register(router.Source{
+		Name:     "http",
+		Priority: 50,
+		Detect: func(event json.RawMessage) bool {
+			var httpEvent events.APIGatewayV2HTTPRequest
+
+			return json.Unmarshal(event, &httpEvent) == nil && httpEvent.RequestContext.HTTP.Method != ""
+		},
+		Handle: router.Typed(Handler),
+	})
+}
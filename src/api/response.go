@@ -0,0 +1,180 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"iss-telemetry-analyzer/src/types"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// listPage is the JSON body of time-range endpoints
+type listPage struct {
+	Items      interface{} `json:"items"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// listResponse renders a page of items, exposing the cursor of the next page
+// in the body for JSON and in the X-Next-Cursor header for CSV
+func listResponse(format string, items interface{}, rows [][]string, cursor string) (events.APIGatewayV2HTTPResponse, error) {
+	if cursor != "" {
+		cursor = base64.RawURLEncoding.EncodeToString([]byte(cursor))
+	}
+
+	if format == "csv" {
+		return csvResponse(rows, cursor)
+	}
+
+	return jsonResponse(listPage{Items: items, NextCursor: cursor})
+}
+
+func jsonResponse(body interface{}) (events.APIGatewayV2HTTPResponse, error) {
+	content, err := json.Marshal(body)
+
+	if err != nil {
+		return internalError(fmt.Errorf("failed to marshal response: %w", err)), nil
+	}
+
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode: http.StatusOK,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(content),
+	}, nil
+}
+
+func csvResponse(rows [][]string, cursor string) (events.APIGatewayV2HTTPResponse, error) {
+	var buffer bytes.Buffer
+
+	writer := csv.NewWriter(&buffer)
+
+	if err := writer.WriteAll(rows); err != nil {
+		return internalError(fmt.Errorf("failed to write CSV: %w", err)), nil
+	}
+
+	headers := map[string]string{"Content-Type": "text/csv"}
+
+	if cursor != "" {
+		headers["X-Next-Cursor"] = cursor
+	}
+
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode: http.StatusOK,
+		Headers:    headers,
+		Body:       buffer.String(),
+	}, nil
+}
+
+func errorResponse(statusCode int, message string) events.APIGatewayV2HTTPResponse {
+	content, _ := json.Marshal(map[string]string{"error": message})
+
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode: statusCode,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(content),
+	}
+}
+
+func internalError(err error) events.APIGatewayV2HTTPResponse {
+	fmt.Printf("Error serving query: %v\n", err)
+
+	return errorResponse(http.StatusInternalServerError, "internal error")
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// processedDataCSV writes one row per bucket and one column group per channel
+func processedDataCSV(processedData []types.ProcessedData) [][]string {
+	channelSet := map[string]bool{}
+
+	for _, data := range processedData {
+		for name := range data.Channels {
+			channelSet[name] = true
+		}
+	}
+
+	var channelNames []string
+
+	for name := range channelSet {
+		channelNames = append(channelNames, name)
+	}
+
+	sort.Strings(channelNames)
+
+	header := []string{"timestamp", "scored", "anomaly_score", "anomaly_level"}
+
+	for _, name := range channelNames {
+		header = append(header, name+"_value", name+"_change_rate", name+"_state", name+"_count", name+"_stale")
+	}
+
+	rows := [][]string{header}
+
+	for _, data := range processedData {
+		row := []string{data.Timestamp, strconv.FormatBool(data.Scored), formatFloat(data.AnomalyScore), data.AnomalyLevel}
+
+		for _, name := range channelNames {
+			channelValue, ok := data.Channels[name]
+
+			if !ok {
+				row = append(row, "", "", "", "", "")
+				continue
+			}
+
+			row = append(row,
+				formatFloat(channelValue.Value),
+				formatFloat(channelValue.ChangeRate),
+				channelValue.State,
+				strconv.Itoa(channelValue.Count),
+				strconv.FormatBool(channelValue.Stale),
+			)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+func anomalyEventsCSV(anomalyEvents []types.AnomalyEvent) [][]string {
+	rows := [][]string{{"timestamp", "level", "previous_level", "anomaly_score"}}
+
+	for _, event := range anomalyEvents {
+		rows = append(rows, []string{event.Timestamp, event.Level, event.PreviousLevel, formatFloat(event.AnomalyScore)})
+	}
+
+	return rows
+}
+
+// bucketsCSV writes one row per buffered reading
+func bucketsCSV(buckets []types.DynamoData) [][]string {
+	rows := [][]string{{"bucket_key", "finalized", "name", "value", "timestamp"}}
+
+	for _, bucket := range buckets {
+		for _, data := range bucket.Data {
+			rows = append(rows, []string{*bucket.BucketKey, strconv.FormatBool(bucket.Finalized), data.Name, data.Value, data.Timestamp})
+		}
+	}
+
+	return rows
+}
+
+func statsCSV(stats types.ScoreStats) [][]string {
+	return [][]string{
+		{"count", "latest_score", "average", "standard_deviation"},
+		{strconv.Itoa(stats.Count), formatFloat(stats.Latest), formatFloat(stats.Average), formatFloat(stats.StandardDeviation)},
+	}
+}
+
+func sortBuckets(buckets []types.DynamoData) {
+	sort.Slice(buckets, func(i, j int) bool {
+		return *buckets[i].BucketKey < *buckets[j].BucketKey
+	})
+}
@@ -0,0 +1,123 @@
+package buckets
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"iss-telemetry-analyzer/src/channels"
+	"iss-telemetry-analyzer/src/resample"
+	"iss-telemetry-analyzer/src/timestamp"
+	"iss-telemetry-analyzer/src/types"
+)
+
+// Aggregate summarises the readings of one channel within a bucket
+type Aggregate struct {
+	Mean  float64
+	Min   float64
+	Max   float64
+	Last  float64
+	Count int
+	Unit  string // Unit reported with the readings, if any
+	// Readings per state and latest state of discrete channels
+	States    map[string]int
+	LastState string
+	// Readings of discrete channels in event-time order
+	StateReadings []types.StateReading
+	// Event time of the latest reading
+	LastTime time.Time
+	// Readings of numeric channels in arrival order, for resampling
+	Samples []resample.Sample
+
+	sum float64
+}
+
+// AggregateBucket aggregates the readings of a bucket per channel. Readings
+// that cannot be parsed are skipped.
+func AggregateBucket(bucket *types.DynamoData, registry *channels.Registry) map[string]*Aggregate {
+	aggregates := map[string]*Aggregate{}
+
+	for _, data := range bucket.Data {
+		value, err := parseValue(registry, data)
+
+		if err != nil {
+			fmt.Printf("Error parsing %s value %s: %v\n", data.Name, data.Value, err)
+			continue
+		}
+
+		ts, err := timestamp.Parse(data.Timestamp, timestamp.AUTO, time.Now())
+
+		if err != nil {
+			fmt.Printf("Error parsing %s timestamp %s: %v\n", data.Name, data.Timestamp, err)
+			continue
+		}
+
+		aggregate, ok := aggregates[data.Name]
+
+		if !ok {
+			aggregate = &Aggregate{Min: math.Inf(1), Max: math.Inf(-1)}
+			aggregates[data.Name] = aggregate
+		}
+
+		aggregate.Count++
+
+		if value.State == "" {
+			aggregate.Samples = append(aggregate.Samples, resample.Sample{Time: ts, Value: value.Number})
+		}
+
+		if data.Unit != "" {
+			aggregate.Unit = data.Unit
+		}
+		aggregate.sum += value.Number
+		aggregate.Min = math.Min(aggregate.Min, value.Number)
+		aggregate.Max = math.Max(aggregate.Max, value.Number)
+
+		if value.State != "" {
+			if aggregate.States == nil {
+				aggregate.States = map[string]int{}
+			}
+
+			aggregate.States[value.State]++
+			aggregate.StateReadings = append(aggregate.StateReadings, types.StateReading{State: value.State, Time: ts})
+		}
+
+		// Readings are appended in arrival order, which may differ from event order
+		if !ts.Before(aggregate.LastTime) {
+			aggregate.Last = value.Number
+			aggregate.LastState = value.State
+			aggregate.LastTime = ts
+		}
+	}
+
+	for _, aggregate := range aggregates {
+		aggregate.Mean = aggregate.sum / float64(aggregate.Count)
+
+		// Readings of one time keep their arrival order
+		readings := aggregate.StateReadings
+		sort.SliceStable(readings, func(i, j int) bool { return readings[i].Time.Before(readings[j].Time) })
+	}
+
+	return aggregates
+}
+
+// parseValue reads a buffered value by the type of its channel. Values of
+// channels missing from the registry are read as floats.
+func parseValue(registry *channels.Registry, data types.TelemetryData) (channels.Value, error) {
+	if channel, ok := registry.Get(data.Name); ok {
+		return channel.ParseValue(data.Value)
+	}
+
+	number, err := strconv.ParseFloat(data.Value, 64)
+
+	return channels.Value{Number: number}, err
+}
+
+func (a *Aggregate) unit() string {
+	if a == nil {
+		return ""
+	}
+
+	return a.Unit
+}
@@ -0,0 +1,85 @@
+package buckets
+
+import (
+	"time"
+
+	"iss-telemetry-analyzer/src/dynamo"
+	"iss-telemetry-analyzer/src/resample"
+	"iss-telemetry-analyzer/src/types"
+)
+
+// align replaces the mean of each numeric channel with the mean of its
+// points on the resampling grid of the bucket, so that all channels are
+// sampled at the same times. The latest reading before the bucket anchors
+// the interpolation at its start. Channels with no point close enough to a
+// reading are marked missing.
+func (f *Finalizer) align(bucketKey string, processedData *types.ProcessedData, aggregates map[string]*Aggregate, previous *types.ProcessedData) {
+	resampling := f.registry.Resampling
+
+	if resampling == nil {
+		return
+	}
+
+	bucketStart, err := time.Parse(time.RFC3339, bucketKey)
+
+	if err != nil {
+		return
+	}
+
+	grid := resample.Grid(bucketStart, bucketStart.Add(dynamo.BucketDuration), time.Duration(resampling.Period))
+	series := map[string][]resample.Sample{}
+	settings := map[string]resample.Settings{}
+
+	for i := range f.registry.Channels {
+		channel := &f.registry.Channels[i]
+
+		if _, ok := processedData.Channels[channel.Name]; !ok || channel.IsDiscrete() || channel.IsDerived() {
+			continue
+		}
+
+		var samples []resample.Sample
+
+		if previous != nil {
+			if previousValue, ok := previous.Channels[channel.Name]; ok {
+				if lastSeen, err := time.Parse(time.RFC3339, previousValue.LastSeen); err == nil {
+					samples = append(samples, resample.Sample{Time: lastSeen, Value: previousValue.Last})
+				}
+			}
+		}
+
+		if aggregate, ok := aggregates[channel.Name]; ok {
+			samples = append(samples, aggregate.Samples...)
+		}
+
+		series[channel.Name] = samples
+		settings[channel.Name] = f.registry.ResampleSettings(channel)
+	}
+
+	frames := resample.Frames(series, grid, settings)
+
+	for name := range series {
+		sum := 0.0
+		points := 0
+		channelValue := processedData.Channels[name]
+		channelValue.MissingPoints = 0
+
+		for _, frame := range frames {
+			point := frame.Points[name]
+
+			if point.Missing {
+				channelValue.MissingPoints++
+				continue
+			}
+
+			sum += point.Value
+			points++
+		}
+
+		if points > 0 {
+			channelValue.Value = sum / float64(points)
+		}
+
+		channelValue.Missing = points == 0
+		processedData.Channels[name] = channelValue
+	}
+}
@@ -0,0 +1,45 @@
+package buckets
+
+import (
+	"slices"
+	"time"
+
+	"iss-telemetry-analyzer/src/channels"
+	"iss-telemetry-analyzer/src/derivative"
+	"iss-telemetry-analyzer/src/types"
+)
+
+// changeRate estimates the change rate of a channel at the start of a bucket
+// with the estimator of the channel
+func (f *Finalizer) changeRate(channel *channels.Channel, value float64, bucketKey string, previous *types.ProcessedData, history map[string][]derivative.Point) float64 {
+	bucketStart, err := time.Parse(time.RFC3339, bucketKey)
+
+	if err != nil {
+		return 0
+	}
+
+	settings := channel.DerivativeSettings()
+	var points []derivative.Point
+
+	if history != nil {
+		points = slices.Clone(history[channel.Name])
+	}
+
+	// Start from the previous bucket when there is no history yet
+	if len(points) == 0 && previous != nil {
+		previousValue, hasPrevious := previous.Channels[channel.Name]
+		previousStart, err := time.Parse(time.RFC3339, previous.Timestamp)
+
+		if hasPrevious && err == nil {
+			points = append(points, derivative.Point{Time: previousStart, Value: previousValue.Value})
+		}
+	}
+
+	points = append(points, derivative.Point{Time: bucketStart, Value: value})
+
+	if history != nil {
+		history[channel.Name] = derivative.Window(points, settings.Window)
+	}
+
+	return derivative.Estimate(points, settings)
+}
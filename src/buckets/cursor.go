@@ -0,0 +1,104 @@
+package buckets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"iss-telemetry-analyzer/src/correlation"
+	"iss-telemetry-analyzer/src/derivative"
+	"iss-telemetry-analyzer/src/dynamo"
+	"iss-telemetry-analyzer/src/transitions"
+	"iss-telemetry-analyzer/src/types"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// errCursorMoved is returned when another invocation advanced the cursor
+var errCursorMoved = errors.New("bucket cursor was advanced concurrently")
+
+// cursor tracks the last finalized bucket and the data produced from it
+type cursor struct {
+	Key           string               `dynamodbav:"key"`
+	LastFinalized string               `dynamodbav:"LastFinalized,omitempty"`
+	Previous      *types.ProcessedData `dynamodbav:"Previous,omitempty"`
+	LastLevel     string               `dynamodbav:"LastLevel,omitempty"` // Level of the last bucket that has one
+	// Latest values of the numeric channels for change-rate estimation
+	History map[string][]derivative.Point `dynamodbav:"History,omitempty"`
+	// State-transition models of the discrete channels, by channel
+	Transitions map[string]*transitions.Model `dynamodbav:"Transitions,omitempty"`
+	// Learned strength of the couplings, by coupling
+	Couplings map[string]*correlation.Baseline `dynamodbav:"Couplings,omitempty"`
+	Version   int64                            `dynamodbav:"Version"`
+}
+
+func loadCursor(ctx context.Context) (*cursor, error) {
+	client := dynamo.GetDynamoDBClient()
+
+	result, err := client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String("PipelineState"),
+		ConsistentRead: aws.Bool(true),
+		Key: map[string]*dynamodb.AttributeValue{
+			"key": {S: aws.String("bucket_cursor")},
+		},
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch bucket cursor: %w", err)
+	}
+
+	bucketCursor := cursor{Key: "bucket_cursor"}
+
+	if result.Item != nil {
+		if err := dynamodbattribute.UnmarshalMap(result.Item, &bucketCursor); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal bucket cursor: %w", err)
+		}
+	}
+
+	return &bucketCursor, nil
+}
+
+func saveCursor(ctx context.Context, bucketCursor *cursor) error {
+	client := dynamo.GetDynamoDBClient()
+
+	expectedVersion := bucketCursor.Version
+
+	updated := *bucketCursor
+	updated.Version = expectedVersion + 1
+
+	item, err := dynamodbattribute.MarshalMap(updated)
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal bucket cursor: %w", err)
+	}
+
+	_, err = client.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String("PipelineState"),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(#key) OR Version = :expected"),
+		ExpressionAttributeNames: map[string]*string{
+			"#key": aws.String("key"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":expected": {N: aws.String(strconv.FormatInt(expectedVersion, 10))},
+		},
+	})
+
+	var awsErr awserr.Error
+
+	if errors.As(err, &awsErr) && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return errCursorMoved
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to store bucket cursor: %w", err)
+	}
+
+	bucketCursor.Version = updated.Version
+
+	return nil
+}
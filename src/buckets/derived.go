@@ -0,0 +1,99 @@
+package buckets
+
+import (
+	"fmt"
+
+	"iss-telemetry-analyzer/src/derivative"
+	"iss-telemetry-analyzer/src/types"
+)
+
+// bucketEnv exposes the aligned channel values of a bucket and of the
+// previous bucket to derived channel expressions
+type bucketEnv struct {
+	current  *types.ProcessedData
+	previous *types.ProcessedData
+}
+
+func (e bucketEnv) Value(channel string) (float64, bool) {
+	channelValue, ok := e.current.Channels[channel]
+
+	return channelValue.Value, ok
+}
+
+func (e bucketEnv) Previous(channel string) (float64, bool) {
+	if e.previous == nil {
+		return 0, false
+	}
+
+	channelValue, ok := e.previous.Channels[channel]
+
+	return channelValue.Value, ok
+}
+
+func (e bucketEnv) Rate(channel string) (float64, bool) {
+	channelValue, ok := e.current.Channels[channel]
+
+	return channelValue.ChangeRate, ok && e.previous != nil
+}
+
+// derive computes the derived channels of a bucket from the channels already
+// processed. A derived channel is as fresh as its oldest input, missing when
+// any input is, and is left out while any input has no value. Change rates
+// use the history as for the other numeric channels.
+func (f *Finalizer) derive(processedData *types.ProcessedData, previous *types.ProcessedData, history map[string][]derivative.Point) {
+	env := bucketEnv{current: processedData, previous: previous}
+
+	for _, channel := range f.registry.DerivedChannels() {
+		derivation := channel.Derivation()
+		value, err := derivation.Evaluate(env)
+
+		if err != nil {
+			fmt.Printf("Cannot derive %s for bucket %s: %v\n", channel.Name, processedData.Timestamp, err)
+			continue
+		}
+
+		channelValue := types.ChannelValue{
+			Value: value,
+			Min:   value,
+			Max:   value,
+			Last:  value,
+			Unit:  channel.Unit,
+		}
+
+		for _, name := range derivation.References() {
+			input := processedData.Channels[name]
+			channelValue.Count = max(channelValue.Count, input.Count)
+			channelValue.Missing = channelValue.Missing || input.Missing
+
+			if channelValue.LastSeen == "" || input.LastSeen < channelValue.LastSeen {
+				channelValue.LastSeen = input.LastSeen
+			}
+		}
+
+		// Derived channels are estimated like the channels they come from
+		channelValue.ChangeRate = f.changeRate(channel, value, processedData.Timestamp, previous, history)
+
+		processedData.Channels[channel.Name] = channelValue
+	}
+}
+
+// inheritStaleness marks the derived channels of a bucket stale when any of
+// their inputs is. It runs after the staleness check, which cannot tell on
+// its own since derived channels have no max age.
+func (f *Finalizer) inheritStaleness(processedData *types.ProcessedData) {
+	for _, channel := range f.registry.DerivedChannels() {
+		channelValue, ok := processedData.Channels[channel.Name]
+
+		if !ok {
+			continue
+		}
+
+		for _, name := range channel.Derivation().References() {
+			if processedData.Channels[name].Stale {
+				channelValue.Stale = true
+			}
+		}
+
+		processedData.Channels[channel.Name] = channelValue
+	}
+}
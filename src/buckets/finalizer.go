@@ -0,0 +1,469 @@
+package buckets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"iss-telemetry-analyzer/src/channels"
+	"iss-telemetry-analyzer/src/correlation"
+	"iss-telemetry-analyzer/src/derivative"
+	"iss-telemetry-analyzer/src/dynamo"
+	"iss-telemetry-analyzer/src/scoring"
+	"iss-telemetry-analyzer/src/signal"
+	"iss-telemetry-analyzer/src/state"
+	"iss-telemetry-analyzer/src/transitions"
+	"iss-telemetry-analyzer/src/types"
+	"iss-telemetry-analyzer/src/utils"
+	"iss-telemetry-analyzer/src/window"
+)
+
+// Bounds of the buckets walked by a single FinalizeThrough call
+const (
+	maxBucketsPerRun = 720 // One hour of buckets
+	initialLookback  = 12  // Buckets looked back when nothing was finalized yet
+)
+
+// A finalized bucket is held for scoring until the invocation times out, or
+// for the longest Lambda timeout when the context has no deadline
+const (
+	maxScoringHold = 15 * time.Minute
+	holdMargin     = 10 * time.Second
+)
+
+// errBucketScoring is returned while another invocation holds a finalized
+// bucket for scoring. The walk stops there so the bucket is not skipped.
+var errBucketScoring = errors.New("bucket is being scored by another invocation")
+
+// Scorer scores and emits the data produced from a finalized bucket
+type Scorer interface {
+	Score(ctx context.Context, processedData *types.ProcessedData) error
+}
+
+// Publisher pushes scored data to live subscribers. It may keep its
+// subscribers between calls; Refresh is called before each run so that it
+// sees the current ones.
+type Publisher interface {
+	Refresh()
+	Publish(ctx context.Context, processedData types.ProcessedData, previousLevel string) error
+}
+
+// Finalizer closes buckets once their window has passed and scores them
+type Finalizer struct {
+	registry  *channels.Registry
+	scorer    Scorer
+	publisher Publisher
+	detector  *transitions.Detector
+	couplings *correlation.Detector
+	store     state.Store // Keeps the rolling windows across invocations
+}
+
+// NewFinalizer creates a finalizer. The publisher is optional.
+func NewFinalizer(registry *channels.Registry, scorer Scorer, publisher Publisher, store state.Store) *Finalizer {
+	return &Finalizer{
+		registry:  registry,
+		scorer:    scorer,
+		publisher: publisher,
+		detector:  transitions.NewDetector(registry),
+		couplings: correlation.NewDetector(registry),
+		store:     store,
+	}
+}
+
+// FinalizeThrough finalizes, in time order, every bucket that ends at or
+// before the given time and returns the data produced from them
+func (f *Finalizer) FinalizeThrough(ctx context.Context, through time.Time) ([]types.ProcessedData, error) {
+	bucketCursor, err := loadCursor(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	next := through.UTC().Truncate(dynamo.BucketDuration).Add(-initialLookback * dynamo.BucketDuration)
+
+	if bucketCursor.LastFinalized != "" {
+		lastFinalized, err := time.Parse(time.RFC3339, bucketCursor.LastFinalized)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid bucket cursor %s: %w", bucketCursor.LastFinalized, err)
+		}
+
+		next = lastFinalized.Add(dynamo.BucketDuration)
+	}
+
+	rings, err := f.loadWindows(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var finalized []types.ProcessedData
+	var finalizeErr error
+
+	if f.publisher != nil {
+		f.publisher.Refresh()
+	}
+
+	for i := 0; i < maxBucketsPerRun && !next.Add(dynamo.BucketDuration).After(through); i++ {
+		bucketKey := dynamo.BucketKey(next)
+
+		if bucketCursor.History == nil {
+			bucketCursor.History = map[string][]derivative.Point{}
+		}
+
+		processedData, hasData, err := f.finalizeBucket(ctx, bucketKey, bucketCursor.Previous, bucketCursor.History, rings, false)
+
+		if err != nil {
+			finalizeErr = err
+			break
+		}
+
+		if processedData != nil {
+			bucketCursor.Previous = processedData
+		}
+
+		if hasData {
+			f.detectTransitions(bucketCursor, processedData)
+			f.detectDecoupling(bucketCursor, processedData)
+			finalized = append(finalized, *processedData)
+			f.record(ctx, *processedData, bucketCursor.LastLevel)
+			markScored(bucketKey)
+		}
+
+		if hasData && processedData.AnomalyLevel != "" {
+			bucketCursor.LastLevel = processedData.AnomalyLevel
+		}
+
+		bucketCursor.LastFinalized = bucketKey
+		next = next.Add(dynamo.BucketDuration)
+	}
+
+	err = saveCursor(ctx, bucketCursor)
+
+	// The windows belong to the cursor, so only its owner stores them
+	if err == nil {
+		f.saveWindows(ctx, rings)
+	}
+
+	if errors.Is(err, errCursorMoved) {
+		fmt.Println("Bucket cursor was advanced by another invocation")
+		err = nil
+	}
+
+	return finalized, errors.Join(finalizeErr, err)
+}
+
+// finalizeBucket claims, aggregates and scores a bucket. Buckets without
+// data carry the previous values over so staleness keeps being tracked.
+// It returns nil when another invocation finalized the bucket, and whether
+// the bucket had data of its own. Buckets with data stay held for scoring
+// until the caller has recorded them and calls markScored. The change-rate history and the rolling
+// windows are optional. Rescored buckets were already checked for staleness
+// changes, so they flag stale channels without emitting signal events again.
+func (f *Finalizer) finalizeBucket(ctx context.Context, bucketKey string, previous *types.ProcessedData, history map[string][]derivative.Point, rings map[string]*window.Ring, rescore bool) (*types.ProcessedData, bool, error) {
+	bucketStart, err := time.Parse(time.RFC3339, bucketKey)
+
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid bucket key %s: %w", bucketKey, err)
+	}
+
+	bucket, err := dynamo.GetBucket(bucketKey)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Buckets finalized before scoring was tracked have no hold
+	if bucket != nil && bucket.Finalized && (bucket.Scored || bucket.ScoringUntil == 0) {
+		return nil, false, nil
+	}
+
+	// A bucket left unscored by a dead invocation is taken over once its
+	// hold expires
+	if bucket != nil && bucket.Finalized && time.Now().Unix() <= bucket.ScoringUntil {
+		return nil, false, fmt.Errorf("bucket %s: %w", bucketKey, errBucketScoring)
+	}
+
+	if bucket == nil {
+		if previous == nil {
+			return nil, false, nil
+		}
+
+		bucket = &types.DynamoData{}
+	} else {
+		claimed, err := dynamo.MarkBucketFinalized(bucketKey, scoringUntil(ctx))
+
+		if err != nil || !claimed {
+			return nil, false, err
+		}
+	}
+
+	processedData := f.process(bucketKey, bucket, previous, history, rings)
+
+	events := signal.CheckStaleness(f.registry, processedData, previous, bucketStart.Add(dynamo.BucketDuration))
+	f.inheritStaleness(processedData)
+
+	if !rescore {
+		for _, event := range events {
+			signal.Emit(event)
+		}
+	}
+
+	if len(bucket.Data) == 0 {
+		// Nothing is recorded for a bucket without data
+		if bucket.BucketKey != nil {
+			markScored(bucketKey)
+		}
+
+		return processedData, false, nil
+	}
+
+	if reason := f.unscorableReason(processedData); reason != "" {
+		// Never call the model with missing or stale inputs
+		fmt.Printf("Not scoring bucket %s: %s\n", bucketKey, reason)
+		scoring.Emit(*processedData, nil)
+		return processedData, true, nil
+	}
+
+	if err := f.scorer.Score(ctx, processedData); err != nil {
+		// Leave the bucket to be finalized again by the next invocation
+		if reopenErr := dynamo.ReopenBucket(bucketKey); reopenErr != nil {
+			fmt.Printf("Error reopening bucket %s: %v\n", bucketKey, reopenErr)
+		}
+
+		return nil, false, fmt.Errorf("failed to score bucket %s: %w", bucketKey, err)
+	}
+
+	return processedData, true, nil
+}
+
+// scoringUntil is when the hold of a bucket finalized now expires
+func scoringUntil(ctx context.Context) time.Time {
+	if deadline, ok := ctx.Deadline(); ok {
+		return deadline.Add(holdMargin)
+	}
+
+	return time.Now().Add(maxScoringHold)
+}
+
+// markScored releases the hold of a recorded bucket. On failure the hold
+// expires and the bucket is only scored again if the walk comes back to it.
+func markScored(bucketKey string) {
+	if err := dynamo.MarkBucketScored(bucketKey); err != nil {
+		fmt.Printf("Error marking bucket %s scored: %v\n", bucketKey, err)
+	}
+}
+
+// detectTransitions checks the state changes of a bucket with the models
+// kept in the cursor and raises its anomaly level to the transition level
+func (f *Finalizer) detectTransitions(bucketCursor *cursor, processedData *types.ProcessedData) {
+	if bucketCursor.Transitions == nil {
+		bucketCursor.Transitions = map[string]*transitions.Model{}
+	}
+
+	anomalies := f.detector.Observe(bucketCursor.Transitions, processedData)
+
+	if len(anomalies) == 0 {
+		return
+	}
+
+	for _, anomaly := range anomalies {
+		transitions.Emit(anomaly)
+	}
+
+	scoreLevel, _ := utils.ParseAnomalyLevel(processedData.AnomalyLevel)
+	processedData.AnomalyLevel = utils.MaxLevel(scoreLevel, transitions.Level(anomalies)).String()
+	processedData.TransitionAnomalies = anomalies
+}
+
+// detectDecoupling checks the couplings of a bucket with the baselines kept
+// in the cursor and raises its anomaly level to the decoupling level
+func (f *Finalizer) detectDecoupling(bucketCursor *cursor, processedData *types.ProcessedData) {
+	if bucketCursor.Couplings == nil {
+		bucketCursor.Couplings = map[string]*correlation.Baseline{}
+	}
+
+	anomalies := f.couplings.Observe(bucketCursor.Couplings, processedData)
+
+	if len(anomalies) == 0 {
+		return
+	}
+
+	for _, anomaly := range anomalies {
+		correlation.Emit(anomaly)
+	}
+
+	scoreLevel, _ := utils.ParseAnomalyLevel(processedData.AnomalyLevel)
+	processedData.AnomalyLevel = utils.MaxLevel(scoreLevel, correlation.Level(anomalies)).String()
+	processedData.DecouplingAnomalies = anomalies
+}
+
+// record stores the processed data of a bucket, keeps raised or changed
+// anomaly levels as anomaly events and pushes data with a level to
+// subscribers. Failures are only logged so that they never hold back
+// finalization.
+func (f *Finalizer) record(ctx context.Context, processedData types.ProcessedData, previousLevel string) {
+	if err := dynamo.StoreProcessedData(processedData); err != nil {
+		fmt.Printf("Error storing bucket %s: %v\n", processedData.Timestamp, err)
+	}
+
+	// Buckets that were neither scored nor flagged by a detector have no level
+	if processedData.AnomalyLevel == "" {
+		return
+	}
+
+	changed := previousLevel != "" && previousLevel != processedData.AnomalyLevel
+
+	if changed || processedData.AnomalyLevel != utils.NO_ANOMALY.String() {
+		err := dynamo.StoreAnomalyEvent(types.AnomalyEvent{
+			Timestamp:     processedData.Timestamp,
+			Level:         processedData.AnomalyLevel,
+			PreviousLevel: previousLevel,
+			AnomalyScore:  processedData.AnomalyScore,
+
+			TransitionAnomalies: processedData.TransitionAnomalies,
+			DecouplingAnomalies: processedData.DecouplingAnomalies,
+		})
+
+		if err != nil {
+			fmt.Printf("Error storing anomaly event %s: %v\n", processedData.Timestamp, err)
+		}
+	}
+
+	if f.publisher == nil {
+		return
+	}
+
+	if err := f.publisher.Publish(ctx, processedData, previousLevel); err != nil {
+		fmt.Printf("Error publishing bucket %s: %v\n", processedData.Timestamp, err)
+	}
+}
+
+// process builds the processed data of a bucket. Channels without readings
+// in the bucket carry over the last value of the previous bucket. Numeric
+// channels are then aligned, and derived channels computed from the result.
+// Change rates are estimated from the history when one is given, which is
+// then updated, and from the previous bucket otherwise. Window statistics
+// and correlations are computed when rolling windows are given.
+func (f *Finalizer) process(bucketKey string, bucket *types.DynamoData, previous *types.ProcessedData, history map[string][]derivative.Point, rings map[string]*window.Ring) *types.ProcessedData {
+	aggregates := AggregateBucket(bucket, f.registry)
+
+	processedData := &types.ProcessedData{
+		Timestamp: bucketKey,
+		Channels:  map[string]types.ChannelValue{},
+	}
+
+	for _, channel := range f.registry.Channels {
+		if channel.IsDerived() {
+			continue
+		}
+
+		var previousValue types.ChannelValue
+		hasPrevious := false
+
+		if previous != nil {
+			previousValue, hasPrevious = previous.Channels[channel.Name]
+		}
+
+		var channelValue types.ChannelValue
+
+		if aggregate, ok := aggregates[channel.Name]; ok {
+			channelValue = types.ChannelValue{
+				Value:       aggregate.Mean,
+				Min:         aggregate.Min,
+				Max:         aggregate.Max,
+				Last:        aggregate.Last,
+				Count:       aggregate.Count,
+				LastSeen:    aggregate.LastTime.UTC().Format(time.RFC3339),
+				State:       aggregate.LastState,
+				StateCounts: aggregate.States,
+
+				StateReadings: aggregate.StateReadings,
+			}
+		} else if hasPrevious {
+			channelValue = types.ChannelValue{
+				Value:    previousValue.Last,
+				Min:      previousValue.Last,
+				Max:      previousValue.Last,
+				Last:     previousValue.Last,
+				LastSeen: previousValue.LastSeen,
+				State:    previousValue.State,
+			}
+		} else {
+			continue
+		}
+
+		if channel.IsDiscrete() {
+			// A mean of state indexes means nothing, report the latest state
+			channelValue.Value = channelValue.Last
+		}
+
+		channelValue.Unit = channel.Unit
+
+		if channelValue.Unit == "" {
+			channelValue.Unit = aggregates[channel.Name].unit()
+		}
+
+		processedData.Channels[channel.Name] = channelValue
+	}
+
+	f.align(bucketKey, processedData, aggregates, previous)
+
+	for name, channelValue := range processedData.Channels {
+		channel, ok := f.registry.Get(name)
+
+		if !ok || channel.IsDiscrete() {
+			continue
+		}
+
+		channelValue.ChangeRate = f.changeRate(channel, channelValue.Value, bucketKey, previous, history)
+		processedData.Channels[name] = channelValue
+	}
+
+	f.derive(processedData, previous, history)
+	f.correlate(processedData, f.rollWindows(processedData, rings))
+
+	return processedData
+}
+
+// unscorableReason explains why the model cannot be called on the data
+func (f *Finalizer) unscorableReason(processedData *types.ProcessedData) string {
+	var missing, stale []string
+
+	for _, channel := range f.registry.FeatureChannels() {
+		channelValue, ok := processedData.Channels[channel.Name]
+
+		// Channels with gaps too long to resample over are missing too
+		if !ok || channelValue.Missing {
+			missing = append(missing, channel.Name)
+		} else if channelValue.Stale {
+			stale = append(stale, channel.Name)
+		}
+	}
+
+	// Spectra with too few values to estimate
+	for _, channel := range f.registry.FeatureChannels() {
+		if channelValue, ok := processedData.Channels[channel.Name]; ok && channel.HasFeature(channels.SPECTRUM) && channelValue.Spectrum == nil {
+			missing = append(missing, channel.Name)
+		}
+	}
+
+	// Couplings with too few values to correlate
+	for _, coupling := range f.registry.Couplings {
+		if _, ok := processedData.Couplings[coupling.Name]; !ok && len(coupling.Features) > 0 {
+			missing = append(missing, coupling.Name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return "missing feature channels " + strings.Join(missing, ", ")
+	}
+
+	if len(stale) > 0 {
+		return "stale feature channels " + strings.Join(stale, ", ")
+	}
+
+	return ""
+}
@@ -0,0 +1,112 @@
+package buckets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"iss-telemetry-analyzer/src/dynamo"
+	"iss-telemetry-analyzer/src/types"
+)
+
+// LatePolicy decides what happens to records whose bucket was finalized
+type LatePolicy string
+
+const (
+	DROP    LatePolicy = "drop"    // Discard the record
+	RESCORE LatePolicy = "rescore" // Add it to its bucket and score the bucket again
+	DIVERT  LatePolicy = "divert"  // Store it in the late-data table
+)
+
+// LatePolicyFromEnv reads LATE_DATA_POLICY, which defaults to drop
+func LatePolicyFromEnv() (LatePolicy, error) {
+	policy := LatePolicy(os.Getenv("LATE_DATA_POLICY"))
+
+	switch policy {
+	case "":
+		return DROP, nil
+	case DROP, RESCORE, DIVERT:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid LATE_DATA_POLICY %q", policy)
+	}
+}
+
+// LastFinalized returns the start of the last finalized bucket, or zero if
+// no bucket was finalized yet
+func LastFinalized(ctx context.Context) (time.Time, error) {
+	bucketCursor, err := loadCursor(ctx)
+
+	if err != nil || bucketCursor.LastFinalized == "" {
+		return time.Time{}, err
+	}
+
+	lastFinalized, err := time.Parse(time.RFC3339, bucketCursor.LastFinalized)
+
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid bucket cursor %s: %w", bucketCursor.LastFinalized, err)
+	}
+
+	return lastFinalized, nil
+}
+
+// IsLate reports whether the bucket of an event was already finalized
+func IsLate(eventTime time.Time, lastFinalized time.Time) bool {
+	if lastFinalized.IsZero() {
+		return false
+	}
+
+	return !eventTime.UTC().Truncate(dynamo.BucketDuration).After(lastFinalized)
+}
+
+// Refinalize scores a bucket again after late data was added to it. The new
+// score replaces the earlier one, and staleness events are not emitted again.
+func (f *Finalizer) Refinalize(ctx context.Context, bucketKey string) (*types.ProcessedData, error) {
+	bucketStart, err := time.Parse(time.RFC3339, bucketKey)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid bucket key %s: %w", bucketKey, err)
+	}
+
+	// Rebuild the previous bucket so change rates stay comparable
+	var previous *types.ProcessedData
+	previousKey := dynamo.BucketKey(bucketStart.Add(-dynamo.BucketDuration))
+
+	previousBucket, err := dynamo.GetBucket(previousKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if previousBucket != nil {
+		previous = f.process(previousKey, previousBucket, nil, nil, nil)
+	}
+
+	// Only read the windows, they keep following the latest buckets
+	rings, err := f.loadWindows(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := dynamo.ReopenBucket(bucketKey); err != nil {
+		return nil, err
+	}
+
+	// The history belongs to the latest buckets, so the change rate of a
+	// rescored bucket is taken from the previous bucket only
+	processedData, hasData, err := f.finalizeBucket(ctx, bucketKey, previous, nil, rings, true)
+
+	if err == nil && hasData {
+		// A rescore does not move the level of the latest bucket
+		if f.publisher != nil {
+			f.publisher.Refresh()
+		}
+
+		f.record(ctx, *processedData, processedData.AnomalyLevel)
+		markScored(bucketKey)
+	}
+
+	return processedData, err
+}
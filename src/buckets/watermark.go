@@ -0,0 +1,168 @@
+package buckets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"iss-telemetry-analyzer/src/dynamo"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// watermarkPrefix starts the keys of the stream watermarks in PipelineState
+const watermarkPrefix = "watermark#"
+
+// defaultAllowedLateness is used when ALLOWED_LATENESS is not set
+const defaultAllowedLateness = 10 * time.Second
+
+// AllowedLatenessFromEnv reads how long buckets stay open after the latest
+// event time from ALLOWED_LATENESS (a Go duration such as "30s")
+func AllowedLatenessFromEnv() (time.Duration, error) {
+	value := os.Getenv("ALLOWED_LATENESS")
+
+	if value == "" {
+		return defaultAllowedLateness, nil
+	}
+
+	allowedLateness, err := time.ParseDuration(value)
+
+	if err != nil || allowedLateness < 0 {
+		return 0, fmt.Errorf("invalid ALLOWED_LATENESS %q", value)
+	}
+
+	return allowedLateness, nil
+}
+
+// defaultStreamIdleTimeout is used when STREAM_IDLE_TIMEOUT is not set
+const defaultStreamIdleTimeout = 5 * time.Minute
+
+// StreamIdleTimeoutFromEnv reads how long a stream without records keeps
+// holding finalization back from STREAM_IDLE_TIMEOUT (a Go duration such as
+// "5m")
+func StreamIdleTimeoutFromEnv() (time.Duration, error) {
+	value := os.Getenv("STREAM_IDLE_TIMEOUT")
+
+	if value == "" {
+		return defaultStreamIdleTimeout, nil
+	}
+
+	idleTimeout, err := time.ParseDuration(value)
+
+	if err != nil || idleTimeout <= 0 {
+		return 0, fmt.Errorf("invalid STREAM_IDLE_TIMEOUT %q", value)
+	}
+
+	return idleTimeout, nil
+}
+
+// AdvanceWatermark records the latest event time seen on a stream and marks
+// the stream as active
+func AdvanceWatermark(ctx context.Context, stream string, eventTime time.Time) error {
+	client := dynamo.GetDynamoDBClient()
+	key := map[string]*dynamodb.AttributeValue{
+		"key": {S: aws.String(watermarkPrefix + stream)},
+	}
+	now := &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(time.Now().UnixMilli(), 10))}
+
+	// Only ever move the watermark forward
+	_, err := client.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String("PipelineState"),
+		Key:                 key,
+		UpdateExpression:    aws.String("SET MaxEventTime = :eventTime, UpdatedAt = :now"),
+		ConditionExpression: aws.String("attribute_not_exists(MaxEventTime) OR MaxEventTime < :eventTime"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":eventTime": {N: aws.String(strconv.FormatInt(eventTime.UnixMilli(), 10))},
+			":now":       now,
+		},
+	})
+
+	var awsErr awserr.Error
+
+	if errors.As(err, &awsErr) && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		// A later event time is already stored, the stream is still active
+		_, err = client.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+			TableName:                 aws.String("PipelineState"),
+			Key:                       key,
+			UpdateExpression:          aws.String("SET UpdatedAt = :now"),
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":now": now},
+		})
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to advance watermark of %s: %w", stream, err)
+	}
+
+	return nil
+}
+
+// MinWatermark returns the lowest event time reached by the streams that
+// received records within the idle timeout. Buckets are only complete up to
+// the slowest active stream; streams that went idle stop holding them back.
+func MinWatermark(ctx context.Context, idleTimeout time.Duration) (time.Time, error) {
+	client := dynamo.GetDynamoDBClient()
+	activeSince := time.Now().Add(-idleTimeout).UnixMilli()
+
+	var minEventTime time.Time
+	var parseErr error
+
+	err := client.ScanPagesWithContext(ctx, &dynamodb.ScanInput{
+		TableName:                aws.String("PipelineState"),
+		FilterExpression:         aws.String("begins_with(#key, :prefix) AND UpdatedAt >= :activeSince"),
+		ExpressionAttributeNames: map[string]*string{"#key": aws.String("key")},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":prefix":      {S: aws.String(watermarkPrefix)},
+			":activeSince": {N: aws.String(strconv.FormatInt(activeSince, 10))},
+		},
+	}, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			maxEventTime, err := parseMaxEventTime(item)
+
+			if err != nil {
+				parseErr = err
+				return false
+			}
+
+			if minEventTime.IsZero() || maxEventTime.Before(minEventTime) {
+				minEventTime = maxEventTime
+			}
+		}
+
+		return true
+	})
+
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to scan watermarks: %w", err)
+	}
+
+	if parseErr != nil {
+		return time.Time{}, parseErr
+	}
+
+	if minEventTime.IsZero() {
+		return time.Time{}, fmt.Errorf("no active stream has a watermark")
+	}
+
+	return minEventTime, nil
+}
+
+func parseMaxEventTime(item map[string]*dynamodb.AttributeValue) (time.Time, error) {
+	attribute, ok := item["MaxEventTime"]
+
+	if !ok || attribute.N == nil {
+		return time.Time{}, fmt.Errorf("watermark has no MaxEventTime")
+	}
+
+	millis, err := strconv.ParseInt(*attribute.N, 10, 64)
+
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid watermark MaxEventTime %s: %w", *attribute.N, err)
+	}
+
+	return time.UnixMilli(millis).UTC(), nil
+}
@@ -0,0 +1,144 @@
+package buckets
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"iss-telemetry-analyzer/src/correlation"
+	"iss-telemetry-analyzer/src/dynamo"
+	"iss-telemetry-analyzer/src/spectrum"
+	"iss-telemetry-analyzer/src/state"
+	"iss-telemetry-analyzer/src/types"
+	"iss-telemetry-analyzer/src/window"
+)
+
+// loadWindows reads the ring buffers of the channels with rolling windows or
+// couplings from the state store, sized to hold their longest window
+func (f *Finalizer) loadWindows(ctx context.Context) (map[string]*window.Ring, error) {
+	rings := map[string]*window.Ring{}
+
+	for i := range f.registry.Channels {
+		channel := &f.registry.Channels[i]
+
+		length := f.registry.RingLength(channel)
+
+		if length == 0 {
+			continue
+		}
+
+		sensorState, err := f.store.Get(ctx, channel.Name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		capacity := int(length/dynamo.BucketDuration) + 1
+		ring := sensorState.Window
+
+		if ring == nil {
+			ring = window.NewRing(capacity)
+		}
+
+		// The windows may have changed since the ring was stored
+		ring.Resize(capacity)
+		rings[channel.Name] = ring
+	}
+
+	return rings, nil
+}
+
+// saveWindows writes the ring buffers back to the state store. Failures are
+// only logged, the windows then restart from the last stored values.
+func (f *Finalizer) saveWindows(ctx context.Context, rings map[string]*window.Ring) {
+	for name, ring := range rings {
+		_, err := state.Update(ctx, f.store, name, func(sensorState *state.SensorState) error {
+			sensorState.Window = ring
+			return nil
+		})
+
+		if err != nil {
+			fmt.Printf("Error storing rolling window of %s: %v\n", name, err)
+		}
+	}
+}
+
+// rollWindows adds the values of a bucket to the ring buffers and computes
+// the window statistics and spectrum of each channel. Only values of earlier buckets are
+// taken from the rings, so that a rescored bucket sees the same windows. It
+// returns the values the statistics were computed from, by channel.
+func (f *Finalizer) rollWindows(processedData *types.ProcessedData, rings map[string]*window.Ring) map[string][]window.Point {
+	bucketStart, err := time.Parse(time.RFC3339, processedData.Timestamp)
+
+	if err != nil {
+		return nil
+	}
+
+	series := map[string][]window.Point{}
+
+	for name, ring := range rings {
+		channelValue, ok := processedData.Channels[name]
+		channel, known := f.registry.Get(name)
+
+		// Missing values would drag the statistics towards zero
+		if !ok || !known || channelValue.Missing {
+			continue
+		}
+
+		current := window.Point{Time: bucketStart.Unix(), Value: channelValue.Value}
+		var points []window.Point
+
+		for _, point := range ring.Ordered() {
+			if point.Time < current.Time {
+				points = append(points, point)
+			}
+		}
+
+		points = append(points, current)
+		series[name] = points
+
+		if len(channel.Windows) > 0 {
+			channelValue.Windows = window.Compute(points, channel.Horizons())
+		}
+
+		if channel.Spectrum != nil {
+			channelValue.Spectrum = spectrum.Compute(points, channel.SpectrumWindow(), dynamo.BucketDuration, channel.SpectrumSettings())
+		}
+
+		processedData.Channels[name] = channelValue
+
+		ring.Push(current)
+	}
+
+	return series
+}
+
+// correlate computes the correlation of each coupling from the values of
+// its channels
+func (f *Finalizer) correlate(processedData *types.ProcessedData, series map[string][]window.Point) {
+	for _, coupling := range f.registry.Couplings {
+		result, ok := correlation.Compute(
+			series[coupling.Channels[0]],
+			series[coupling.Channels[1]],
+			time.Duration(coupling.Window),
+			time.Duration(coupling.MaxLag),
+			dynamo.BucketDuration,
+		)
+
+		if !ok {
+			continue
+		}
+
+		if processedData.Couplings == nil {
+			processedData.Couplings = map[string]types.CouplingValue{}
+		}
+
+		processedData.Couplings[coupling.Name] = types.CouplingValue{
+			Channels:         coupling.Channels,
+			Pearson:          result.Pearson,
+			CrossCorrelation: result.CrossCorrelation,
+			Lag:              result.Lag,
+			Pairs:            result.Pairs,
+		}
+	}
+}
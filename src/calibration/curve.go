@@ -0,0 +1,82 @@
+package calibration
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Curve turns raw values, such as sensor counts, into engineering values.
+// Either polynomial coefficients or a lookup table is given.
+type Curve struct {
+	// Coefficients from the constant term up: c0 + c1*x + c2*x^2 + ...
+	Polynomial []float64 `json:"polynomial" yaml:"polynomial"`
+	// Raw and engineering value pairs, interpolated linearly. Raw values
+	// outside the table are rejected.
+	Table [][]float64 `json:"table" yaml:"table"`
+	// Unit of the engineering values, converted to the channel unit
+	Unit string `json:"unit" yaml:"unit"`
+}
+
+// Validate checks the curve and sorts its table by raw value
+func (c *Curve) Validate() error {
+	if len(c.Polynomial) > 0 && len(c.Table) > 0 {
+		return errors.New("calibration declares both a polynomial and a table")
+	}
+
+	if len(c.Table) == 1 {
+		return errors.New("calibration table needs at least two points")
+	}
+
+	for _, point := range c.Table {
+		if len(point) != 2 {
+			return errors.New("calibration table points need a raw and an engineering value")
+		}
+	}
+
+	sort.Slice(c.Table, func(i, j int) bool { return c.Table[i][0] < c.Table[j][0] })
+
+	for i := 1; i < len(c.Table); i++ {
+		if c.Table[i][0] == c.Table[i-1][0] {
+			return fmt.Errorf("calibration table lists raw value %v twice", c.Table[i][0])
+		}
+	}
+
+	return nil
+}
+
+// Apply returns the engineering value of a raw value
+func (c *Curve) Apply(raw float64) (float64, error) {
+	switch {
+	case len(c.Polynomial) > 0:
+		// Horner's method
+		value := 0.0
+
+		for i := len(c.Polynomial) - 1; i >= 0; i-- {
+			value = value*raw + c.Polynomial[i]
+		}
+
+		return value, nil
+
+	case len(c.Table) > 0:
+		first, last := c.Table[0], c.Table[len(c.Table)-1]
+
+		if math.IsNaN(raw) || raw < first[0] || raw > last[0] {
+			return 0, fmt.Errorf("raw value %v is outside the calibration table [%v, %v]", raw, first[0], last[0])
+		}
+
+		i := sort.Search(len(c.Table), func(i int) bool { return c.Table[i][0] >= raw })
+
+		if c.Table[i][0] == raw {
+			return c.Table[i][1], nil
+		}
+
+		low, high := c.Table[i-1], c.Table[i]
+
+		return low[1] + (raw-low[0])*(high[1]-low[1])/(high[0]-low[0]), nil
+
+	default:
+		return raw, nil
+	}
+}
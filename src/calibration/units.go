@@ -0,0 +1,112 @@
+package calibration
+
+import (
+	"fmt"
+	"strings"
+)
+
+// unit converts to the base unit of its quantity: base = value*scale + offset
+type unit struct {
+	quantity string
+	scale    float64
+	offset   float64
+}
+
+const poundMass = 0.45359237 // kg
+
+// units are keyed by their normalised symbol, see normalise
+var units = map[string]unit{
+	// Pressure, in Pa. Gauge pressures are not supported as they depend on
+	// the ambient pressure.
+	"pa":   {"pressure", 1, 0},
+	"hpa":  {"pressure", 100, 0},
+	"kpa":  {"pressure", 1e3, 0},
+	"mpa":  {"pressure", 1e6, 0},
+	"mbar": {"pressure", 100, 0},
+	"bar":  {"pressure", 1e5, 0},
+	"psi":  {"pressure", 6894.757293168, 0},
+	"psia": {"pressure", 6894.757293168, 0},
+	"mmhg": {"pressure", 133.322387415, 0},
+	"torr": {"pressure", 101325.0 / 760, 0},
+	"atm":  {"pressure", 101325, 0},
+
+	// Temperature, in K
+	"k":    {"temperature", 1, 0},
+	"degc": {"temperature", 1, 273.15},
+	"degf": {"temperature", 5.0 / 9, 273.15 - 32*5.0/9},
+
+	// Mass flow, in kg/s
+	"kg/s":    {"mass flow", 1, 0},
+	"kg/min":  {"mass flow", 1.0 / 60, 0},
+	"kg/h":    {"mass flow", 1.0 / 3600, 0},
+	"g/s":     {"mass flow", 1e-3, 0},
+	"lbm/s":   {"mass flow", poundMass, 0},
+	"lbm/min": {"mass flow", poundMass / 60, 0},
+	"lbm/h":   {"mass flow", poundMass / 3600, 0},
+}
+
+// aliases map other spellings to the symbols of units
+var aliases = map[string]string{
+	"c":       "degc",
+	"celsius": "degc",
+	"f":       "degf",
+	"kg/hr":   "kg/h",
+	"lb/s":    "lbm/s",
+	"lb/min":  "lbm/min",
+	"lb/h":    "lbm/h",
+	"lb/hr":   "lbm/h",
+	"lbm/hr":  "lbm/h",
+	"lbs/hr":  "lbm/h",
+}
+
+// normalise lowercases a unit symbol and spells degrees out, e.g. °F is degf
+func normalise(symbol string) string {
+	symbol = strings.ToLower(strings.TrimSpace(symbol))
+	symbol = strings.ReplaceAll(symbol, "°", "deg")
+	symbol = strings.ReplaceAll(symbol, " ", "")
+
+	if alias, ok := aliases[symbol]; ok {
+		return alias
+	}
+
+	return symbol
+}
+
+// SameUnit reports whether two symbols name the same unit
+func SameUnit(a string, b string) bool {
+	return normalise(a) == normalise(b)
+}
+
+// Convert converts a value between units of the same quantity
+func Convert(value float64, from string, to string) (float64, error) {
+	if SameUnit(from, to) {
+		return value, nil
+	}
+
+	fromUnit, ok := units[normalise(from)]
+
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q", from)
+	}
+
+	toUnit, ok := units[normalise(to)]
+
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q", to)
+	}
+
+	if fromUnit.quantity != toUnit.quantity {
+		return 0, fmt.Errorf("cannot convert %s %s to %s %s", fromUnit.quantity, from, toUnit.quantity, to)
+	}
+
+	base := value*fromUnit.scale + fromUnit.offset
+
+	return (base - toUnit.offset) / toUnit.scale, nil
+}
+
+// CanConvert reports whether values can be converted between the units
+func CanConvert(from string, to string) bool {
+	_, err := Convert(0, from, to)
+
+	return err == nil
+}
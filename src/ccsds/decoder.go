@@ -0,0 +1,115 @@
+package ccsds
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"iss-telemetry-analyzer/src/types"
+)
+
+// Decoder maps CCSDS space packets to telemetry readings
+type Decoder struct {
+	definitions *Definitions
+	byAPID      map[uint16]PacketDefinition
+}
+
+func NewDecoder(definitions *Definitions) *Decoder {
+	byAPID := map[uint16]PacketDefinition{}
+
+	for _, packet := range definitions.Packets {
+		byAPID[packet.APID] = packet
+	}
+
+	return &Decoder{definitions: definitions, byAPID: byAPID}
+}
+
+// NewDecoderFromEnv loads the packet definitions from
+// CCSDS_PACKET_DEFINITIONS, a local path or an s3://bucket/key location
+func NewDecoderFromEnv() (*Decoder, error) {
+	location := os.Getenv("CCSDS_PACKET_DEFINITIONS")
+
+	if location == "" {
+		return nil, errors.New("CCSDS_PACKET_DEFINITIONS is not set")
+	}
+
+	definitions, err := LoadDefinitions(location)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewDecoder(definitions), nil
+}
+
+// Decode returns the parameters of the defined packets in a payload of
+// concatenated space packets. Packets of unknown APIDs are skipped.
+func (d *Decoder) Decode(payload []byte) ([]types.TelemetryData, error) {
+	packets, err := SplitPackets(payload)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var readings []types.TelemetryData
+
+	for _, packet := range packets {
+		definition, ok := d.byAPID[packet.Header.APID]
+
+		if !ok {
+			continue
+		}
+
+		packetReadings, err := d.decodePacket(packet, definition)
+
+		if err != nil {
+			return nil, fmt.Errorf("APID %d sequence count %d: %w", packet.Header.APID, packet.Header.SequenceCount, err)
+		}
+
+		readings = append(readings, packetReadings...)
+	}
+
+	return readings, nil
+}
+
+func (d *Decoder) decodePacket(packet Packet, definition PacketDefinition) ([]types.TelemetryData, error) {
+	if !packet.Header.SecondaryHeader {
+		return nil, errors.New("packet has no secondary header to take its time from")
+	}
+
+	packetTime, err := d.definitions.TimeCode.Decode(packet.Data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	userData := packet.Data[d.definitions.TimeCode.Size():]
+	timestamp := packetTime.Format(time.RFC3339Nano)
+
+	// The sequence is checked once the packet is known not to be a duplicate
+	sequence := &types.PacketSequence{
+		APID:          packet.Header.APID,
+		SequenceCount: packet.Header.SequenceCount,
+		Time:          timestamp,
+	}
+	readings := make([]types.TelemetryData, 0, len(definition.Parameters))
+
+	for _, parameter := range definition.Parameters {
+		value, err := parameter.Extract(userData)
+
+		if err != nil {
+			return nil, err
+		}
+
+		readings = append(readings, types.TelemetryData{
+			Name:      parameter.Name,
+			Value:     strconv.FormatFloat(value, 'f', -1, 64),
+			Timestamp: timestamp,
+			Packet:    sequence,
+		})
+	}
+
+	return readings, nil
+}
@@ -0,0 +1,163 @@
+package ccsds
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"iss-telemetry-analyzer/src/config"
+)
+
+// ParameterType is the binary encoding of a parameter. Values are big-endian.
+type ParameterType string
+
+const (
+	UINT8   ParameterType = "uint8"
+	INT8    ParameterType = "int8"
+	UINT16  ParameterType = "uint16"
+	INT16   ParameterType = "int16"
+	UINT32  ParameterType = "uint32"
+	INT32   ParameterType = "int32"
+	FLOAT32 ParameterType = "float32"
+	FLOAT64 ParameterType = "float64"
+)
+
+var parameterSizes = map[ParameterType]int{
+	UINT8:   1,
+	INT8:    1,
+	UINT16:  2,
+	INT16:   2,
+	UINT32:  4,
+	INT32:   4,
+	FLOAT32: 4,
+	FLOAT64: 8,
+}
+
+// Parameter locates a telemetry value in the user data of a packet, the
+// bytes following the secondary header
+type Parameter struct {
+	Name   string        `json:"name"`
+	Offset int           `json:"offset"`
+	Type   ParameterType `json:"type"`
+	Scale  *float64      `json:"scale,omitempty"` // Defaults to 1
+	Bias   float64       `json:"bias,omitempty"`
+}
+
+// PacketDefinition lists the parameters of the packets of one APID
+type PacketDefinition struct {
+	APID       uint16      `json:"apid"`
+	Name       string      `json:"name"`
+	Parameters []Parameter `json:"parameters"`
+}
+
+// Definitions describe the secondary header time code and the packets
+// to decode
+type Definitions struct {
+	TimeCode TimeCode           `json:"time_code"`
+	Packets  []PacketDefinition `json:"packets"`
+}
+
+// LoadDefinitions reads packet definitions from a local file or an
+// s3://bucket/key location
+func LoadDefinitions(location string) (*Definitions, error) {
+	content, err := config.ReadSource(location)
+
+	if err != nil {
+		return nil, err
+	}
+
+	definitions, err := ParseDefinitions(content)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid packet definitions %s: %w", location, err)
+	}
+
+	return definitions, nil
+}
+
+// ParseDefinitions reads and validates JSON packet definitions
+func ParseDefinitions(content []byte) (*Definitions, error) {
+	var definitions Definitions
+
+	if err := json.Unmarshal(content, &definitions); err != nil {
+		return nil, err
+	}
+
+	if definitions.TimeCode.Epoch.IsZero() {
+		definitions.TimeCode.Epoch = defaultEpoch
+	}
+
+	if err := definitions.TimeCode.validate(); err != nil {
+		return nil, err
+	}
+
+	seen := map[uint16]bool{}
+
+	for _, packet := range definitions.Packets {
+		if packet.APID > 0x07ff {
+			return nil, fmt.Errorf("APID %d does not fit in 11 bits", packet.APID)
+		}
+
+		if seen[packet.APID] {
+			return nil, fmt.Errorf("duplicate definition of APID %d", packet.APID)
+		}
+
+		seen[packet.APID] = true
+
+		for _, parameter := range packet.Parameters {
+			if parameter.Name == "" {
+				return nil, fmt.Errorf("parameter without a name in APID %d", packet.APID)
+			}
+
+			if _, ok := parameterSizes[parameter.Type]; !ok {
+				return nil, fmt.Errorf("parameter %s has unsupported type %q", parameter.Name, parameter.Type)
+			}
+
+			if parameter.Offset < 0 {
+				return nil, fmt.Errorf("parameter %s has a negative offset", parameter.Name)
+			}
+		}
+	}
+
+	return &definitions, nil
+}
+
+// Extract reads the engineering value of the parameter from user data
+func (p Parameter) Extract(userData []byte) (float64, error) {
+	size := parameterSizes[p.Type]
+
+	if p.Offset+size > len(userData) {
+		return 0, fmt.Errorf("parameter %s at offset %d exceeds the %d bytes of user data", p.Name, p.Offset, len(userData))
+	}
+
+	b := userData[p.Offset : p.Offset+size]
+	var raw float64
+
+	switch p.Type {
+	case UINT8:
+		raw = float64(b[0])
+	case INT8:
+		raw = float64(int8(b[0]))
+	case UINT16:
+		raw = float64(binary.BigEndian.Uint16(b))
+	case INT16:
+		raw = float64(int16(binary.BigEndian.Uint16(b)))
+	case UINT32:
+		raw = float64(binary.BigEndian.Uint32(b))
+	case INT32:
+		raw = float64(int32(binary.BigEndian.Uint32(b)))
+	case FLOAT32:
+		raw = float64(math.Float32frombits(binary.BigEndian.Uint32(b)))
+	case FLOAT64:
+		raw = math.Float64frombits(binary.BigEndian.Uint64(b))
+	}
+
+	scale := 1.0
+
+	if p.Scale != nil {
+		scale = *p.Scale
+	}
+
+	return raw*scale + p.Bias, nil
+}
@@ -0,0 +1,70 @@
+package ccsds
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// primaryHeaderSize is the length of the CCSDS space packet primary header
+const primaryHeaderSize = 6
+
+// sequenceCountModulo is the range of the 14-bit packet sequence count
+const sequenceCountModulo = 1 << 14
+
+// PrimaryHeader is the fixed header of a CCSDS space packet
+type PrimaryHeader struct {
+	Version         uint8
+	Type            uint8 // 0 for telemetry, 1 for telecommand
+	SecondaryHeader bool
+	APID            uint16
+	SequenceFlags   uint8
+	SequenceCount   uint16
+	DataLength      int // Length of the packet data field in bytes
+}
+
+// Packet is a space packet with its packet data field, which starts with
+// the secondary header when present
+type Packet struct {
+	Header PrimaryHeader
+	Data   []byte
+}
+
+// SplitPackets reads the space packets concatenated in a payload
+func SplitPackets(payload []byte) ([]Packet, error) {
+	var packets []Packet
+
+	for offset := 0; offset < len(payload); {
+		if len(payload)-offset < primaryHeaderSize {
+			return nil, fmt.Errorf("truncated primary header at byte %d", offset)
+		}
+
+		header := parsePrimaryHeader(payload[offset : offset+primaryHeaderSize])
+		start := offset + primaryHeaderSize
+		end := start + header.DataLength
+
+		if end > len(payload) {
+			return nil, fmt.Errorf("packet of APID %d at byte %d is truncated", header.APID, offset)
+		}
+
+		packets = append(packets, Packet{Header: header, Data: payload[start:end]})
+		offset = end
+	}
+
+	return packets, nil
+}
+
+func parsePrimaryHeader(b []byte) PrimaryHeader {
+	identification := binary.BigEndian.Uint16(b[0:2])
+	sequenceControl := binary.BigEndian.Uint16(b[2:4])
+
+	return PrimaryHeader{
+		Version:         uint8(identification >> 13),
+		Type:            uint8(identification>>12) & 0x1,
+		SecondaryHeader: identification&0x0800 != 0,
+		APID:            identification & 0x07ff,
+		SequenceFlags:   uint8(sequenceControl >> 14),
+		SequenceCount:   sequenceControl & 0x3fff,
+		// The length field holds the data field length minus one
+		DataLength: int(binary.BigEndian.Uint16(b[4:6])) + 1,
+	}
+}
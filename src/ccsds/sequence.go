@@ -0,0 +1,87 @@
+package ccsds
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"iss-telemetry-analyzer/src/state"
+	"iss-telemetry-analyzer/src/types"
+)
+
+// DataLossEvent reports packets missing from the sequence of an APID
+type DataLossEvent struct {
+	APID          uint16 `json:"apid"`
+	Timestamp     string `json:"timestamp"` // Time of the packet following the gap
+	ExpectedCount uint16 `json:"expected_sequence_count"`
+	ReceivedCount uint16 `json:"received_sequence_count"`
+	Missing       int    `json:"missing_packets"`
+}
+
+// SequenceKey is the key of the sensor state that keeps the last sequence
+// count of an APID
+func SequenceKey(apid uint16) string {
+	return fmt.Sprintf("ccsds:apid:%d", apid)
+}
+
+// TrackSequence records the sequence count of a packet in the state store and
+// returns a data-loss event when packets were skipped since the last packet
+// of the APID. The count is stored with a conditional write, so concurrent
+// Lambda instances see one sequence per APID. Reordered packets, which appear
+// to jump back, are ignored and do not move the baseline. The caller must
+// skip packets that were already tracked, which would otherwise look like a
+// full wrap of the sequence count.
+func TrackSequence(ctx context.Context, store state.Store, sequence types.PacketSequence) (*DataLossEvent, error) {
+	var event *DataLossEvent
+
+	_, err := state.Update(ctx, store, SequenceKey(sequence.APID), func(sensorState *state.SensorState) error {
+		event = nil
+		last := sensorState.SequenceCount
+
+		if last != nil {
+			expected := (*last + 1) % sequenceCountModulo
+			missing := (int(sequence.SequenceCount) - int(expected) + sequenceCountModulo) % sequenceCountModulo
+
+			if missing >= sequenceCountModulo/2 {
+				return nil
+			}
+
+			if missing > 0 {
+				event = &DataLossEvent{
+					APID:          sequence.APID,
+					Timestamp:     sequence.Time,
+					ExpectedCount: expected,
+					ReceivedCount: sequence.SequenceCount,
+					Missing:       missing,
+				}
+			}
+		}
+
+		count := sequence.SequenceCount
+		sensorState.SequenceCount = &count
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to track sequence of APID %d: %w", sequence.APID, err)
+	}
+
+	return event, nil
+}
+
+// EmitDataLoss logs a data-loss event
+func EmitDataLoss(event DataLossEvent) {
+	logData := map[string]interface{}{
+		"log_type": "data_loss_event",
+		"event":    event,
+	}
+
+	logDataBytes, err := json.Marshal(logData)
+
+	if err != nil {
+		fmt.Printf("Error marshaling data loss event: %v\n", err)
+		return
+	}
+
+	fmt.Println(string(logDataBytes))
+}
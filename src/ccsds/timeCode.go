@@ -0,0 +1,60 @@
+package ccsds
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// defaultEpoch is the CCSDS recommended epoch, 1958-01-01 TAI
+var defaultEpoch = time.Date(1958, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// TimeCode describes the CCSDS unsegmented time code (CUC) carried in the
+// secondary header. No P-field is expected in the packets.
+type TimeCode struct {
+	CoarseOctets int       `json:"coarse_octets"` // Whole seconds, 1 to 4 octets
+	FineOctets   int       `json:"fine_octets"`   // Binary fractions of a second, 0 to 3 octets
+	Epoch        time.Time `json:"epoch"`
+	LeapSeconds  int       `json:"leap_seconds"` // Subtracted to convert TAI based codes to UTC
+}
+
+// Size is the number of octets of the time code
+func (t TimeCode) Size() int {
+	return t.CoarseOctets + t.FineOctets
+}
+
+func (t TimeCode) validate() error {
+	if t.CoarseOctets < 1 || t.CoarseOctets > 4 {
+		return fmt.Errorf("coarse_octets must be between 1 and 4, got %d", t.CoarseOctets)
+	}
+
+	if t.FineOctets < 0 || t.FineOctets > 3 {
+		return fmt.Errorf("fine_octets must be between 0 and 3, got %d", t.FineOctets)
+	}
+
+	return nil
+}
+
+// Decode converts the time code at the start of b to UTC
+func (t TimeCode) Decode(b []byte) (time.Time, error) {
+	if len(b) < t.Size() {
+		return time.Time{}, fmt.Errorf("time code needs %d octets, got %d", t.Size(), len(b))
+	}
+
+	var coarse uint64
+
+	for _, octet := range b[:t.CoarseOctets] {
+		coarse = coarse<<8 | uint64(octet)
+	}
+
+	var fine uint64
+
+	for _, octet := range b[t.CoarseOctets:t.Size()] {
+		fine = fine<<8 | uint64(octet)
+	}
+
+	fraction := float64(fine) / math.Pow(256, float64(t.FineOctets))
+	offset := time.Duration(coarse)*time.Second + time.Duration(fraction*float64(time.Second))
+
+	return t.Epoch.Add(offset - time.Duration(t.LeapSeconds)*time.Second).UTC(), nil
+}
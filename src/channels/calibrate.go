@@ -0,0 +1,63 @@
+package channels
+
+import (
+	"fmt"
+
+	"iss-telemetry-analyzer/src/calibration"
+)
+
+// NeedsCalibration reports whether readings reported in the given unit are
+// changed by Calibrate
+func (c *Channel) NeedsCalibration(unit string) bool {
+	if c.Calibration != nil {
+		return true
+	}
+
+	return unit != "" && c.Unit != "" && !calibration.SameUnit(unit, c.Unit)
+}
+
+// Calibrate applies the calibration curve of the channel to a raw numeric
+// value reported in the given unit, then converts it to the channel unit
+func (c *Channel) Calibrate(raw float64, unit string) (float64, error) {
+	value := raw
+
+	if c.Calibration != nil {
+		calibrated, err := c.Calibration.Apply(raw)
+
+		if err != nil {
+			return 0, err
+		}
+
+		value = calibrated
+
+		if c.Calibration.Unit != "" {
+			unit = c.Calibration.Unit
+		}
+	}
+
+	if unit == "" || c.Unit == "" {
+		return value, nil
+	}
+
+	return calibration.Convert(value, unit, c.Unit)
+}
+
+func (c *Channel) validateCalibration() error {
+	if c.Calibration == nil {
+		return nil
+	}
+
+	if c.Type != FLOAT {
+		return fmt.Errorf("channel %s is calibrated but is not a float channel", c.Name)
+	}
+
+	if err := c.Calibration.Validate(); err != nil {
+		return fmt.Errorf("channel %s: %w", c.Name, err)
+	}
+
+	if c.Calibration.Unit != "" && c.Unit != "" && !calibration.CanConvert(c.Calibration.Unit, c.Unit) {
+		return fmt.Errorf("channel %s cannot convert calibrated %s values to %s", c.Name, c.Calibration.Unit, c.Unit)
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package channels
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Roles of the features of a coupling
+const (
+	PEARSON           Role = "pearson"
+	CROSS_CORRELATION Role = "cross_correlation" // Strongest correlation at any lag
+	LAG               Role = "lag"               // Lag of the strongest correlation in seconds
+)
+
+// couplingRoles is the order in which the roles of a coupling are laid out
+var couplingRoles = []Role{PEARSON, CROSS_CORRELATION, LAG}
+
+// defaultCouplingWindow is the sliding window of couplings that set none
+const defaultCouplingWindow = Duration(10 * time.Minute)
+
+// Coupling is a pair of physically coupled numeric channels whose
+// correlation is tracked over a sliding window of bucket values
+type Coupling struct {
+	// Names the features of the coupling, e.g. FLOWRATE~PRESSURE.pearson.
+	// Defaults to the channel names joined by a tilde.
+	Name     string   `json:"name" yaml:"name"`
+	Channels []string `json:"channels" yaml:"channels"`
+	Window   Duration `json:"window" yaml:"window"`
+	// Largest shift of the second channel searched for the best-lag
+	// correlation, zero to only correlate at lag zero
+	MaxLag   Duration `json:"max_lag" yaml:"max_lag"`
+	Features []Role   `json:"features" yaml:"features"`
+}
+
+func (c *Coupling) HasFeature(role Role) bool {
+	return slices.Contains(c.Features, role)
+}
+
+// RingLength returns how far back the bucket values of a channel are kept,
+// covering its rolling windows, its spectrum and the couplings it is part of
+func (r *Registry) RingLength(channel *Channel) time.Duration {
+	length := max(channel.LongestWindow(), channel.SpectrumWindow())
+
+	for _, coupling := range r.Couplings {
+		if slices.Contains(coupling.Channels, channel.Name) {
+			length = max(length, time.Duration(coupling.Window+coupling.MaxLag))
+		}
+	}
+
+	return length
+}
+
+func (r *Registry) initCouplings() error {
+	names := map[string]bool{}
+
+	for i := range r.Couplings {
+		coupling := &r.Couplings[i]
+
+		if len(coupling.Channels) != 2 || coupling.Channels[0] == coupling.Channels[1] {
+			return fmt.Errorf("coupling %d must name two different channels", i)
+		}
+
+		for _, name := range coupling.Channels {
+			channel, ok := r.Get(name)
+
+			if !ok {
+				return fmt.Errorf("coupling %d refers to unknown channel %s", i, name)
+			}
+
+			if channel.IsDiscrete() {
+				return fmt.Errorf("coupling %d refers to discrete channel %s", i, name)
+			}
+		}
+
+		if coupling.Name == "" {
+			coupling.Name = strings.Join(coupling.Channels, "~")
+		}
+
+		if strings.Contains(coupling.Name, ".") {
+			return fmt.Errorf("coupling %s cannot contain dots", coupling.Name)
+		}
+
+		if _, isChannel := r.byName[coupling.Name]; isChannel || names[coupling.Name] {
+			return fmt.Errorf("coupling %s is declared twice or named after a channel", coupling.Name)
+		}
+
+		names[coupling.Name] = true
+
+		if coupling.Window == 0 {
+			coupling.Window = defaultCouplingWindow
+		}
+
+		if coupling.Window < 0 || coupling.MaxLag < 0 || coupling.MaxLag >= coupling.Window {
+			return fmt.Errorf("coupling %s must have a positive window longer than its max lag", coupling.Name)
+		}
+
+		for _, role := range coupling.Features {
+			if !slices.Contains(couplingRoles, role) {
+				return fmt.Errorf("coupling %s has unknown feature %s", coupling.Name, role)
+			}
+		}
+	}
+
+	return nil
+}
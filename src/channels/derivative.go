@@ -0,0 +1,32 @@
+package channels
+
+import (
+	"fmt"
+
+	"iss-telemetry-analyzer/src/derivative"
+)
+
+// DerivativeSettings returns how the change rate of the channel is estimated
+func (c *Channel) DerivativeSettings() derivative.Settings {
+	if c.Derivative == nil {
+		return derivative.DefaultSettings
+	}
+
+	return *c.Derivative
+}
+
+func (c *Channel) validateDerivative() error {
+	if c.Derivative == nil {
+		return nil
+	}
+
+	if c.IsDiscrete() {
+		return fmt.Errorf("discrete channel %s has no change rate to estimate", c.Name)
+	}
+
+	if err := c.Derivative.Validate(); err != nil {
+		return fmt.Errorf("channel %s: %w", c.Name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package channels
+
+import (
+	"fmt"
+
+	"iss-telemetry-analyzer/src/expr"
+)
+
+// IsDerived reports whether the channel is computed from other channels
+func (c *Channel) IsDerived() bool {
+	return c.Expression != ""
+}
+
+// Derivation returns the compiled expression of a derived channel
+func (c *Channel) Derivation() *expr.Expression {
+	return c.derivation
+}
+
+// DerivedChannels returns the derived channels in an order where every
+// channel comes after the derived channels it refers to
+func (r *Registry) DerivedChannels() []*Channel {
+	derived := make([]*Channel, len(r.derivedOrder))
+
+	for i, index := range r.derivedOrder {
+		derived[i] = &r.Channels[index]
+	}
+
+	return derived
+}
+
+// initDerived compiles the expressions of derived channels and orders them
+func (r *Registry) initDerived() error {
+	r.derivedOrder = nil
+
+	for i := range r.Channels {
+		channel := &r.Channels[i]
+
+		if !channel.IsDerived() {
+			continue
+		}
+
+		if channel.Type != FLOAT || channel.Calibration != nil {
+			return fmt.Errorf("derived channel %s must be an uncalibrated float channel", channel.Name)
+		}
+
+		derivation, err := expr.Parse(channel.Expression)
+
+		if err != nil {
+			return fmt.Errorf("invalid expression of channel %s: %w", channel.Name, err)
+		}
+
+		for _, name := range derivation.References() {
+			if _, ok := r.byName[name]; !ok {
+				return fmt.Errorf("expression of channel %s refers to unknown channel %s", channel.Name, name)
+			}
+		}
+
+		channel.derivation = derivation
+	}
+
+	// Depth-first ordering that rejects cycles
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	marks := make([]int, len(r.Channels))
+
+	var visit func(i int) error
+
+	visit = func(i int) error {
+		channel := &r.Channels[i]
+
+		switch marks[i] {
+		case visiting:
+			return fmt.Errorf("derived channel %s is part of a dependency cycle", channel.Name)
+		case visited:
+			return nil
+		}
+
+		marks[i] = visiting
+
+		for _, name := range channel.derivation.References() {
+			if dependency := r.byName[name]; r.Channels[dependency].IsDerived() {
+				if err := visit(dependency); err != nil {
+					return err
+				}
+			}
+		}
+
+		marks[i] = visited
+		r.derivedOrder = append(r.derivedOrder, i)
+
+		return nil
+	}
+
+	for i := range r.Channels {
+		if r.Channels[i].IsDerived() {
+			if err := visit(i); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package channels
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Duration is a time.Duration written as a Go duration string, e.g. "30s"
+type Duration time.Duration
+
+func (d *Duration) parse(value string) error {
+	parsed, err := time.ParseDuration(value)
+
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", value, err)
+	}
+
+	*d = Duration(parsed)
+
+	return nil
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	return d.parse(value)
+}
+
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value string
+
+	if err := unmarshal(&value); err != nil {
+		return err
+	}
+
+	return d.parse(value)
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
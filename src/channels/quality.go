@@ -0,0 +1,37 @@
+package channels
+
+import "fmt"
+
+// Quality tunes the data-quality checks of a numeric channel that depend on
+// its earlier readings. Readings are always checked for invalid numbers and
+// against the valid range of the channel.
+type Quality struct {
+	// Largest change from the last accepted reading. A larger jump is a
+	// spike unless the next reading confirms it. Not checked when not set.
+	MaxJump *float64 `json:"max_jump" yaml:"max_jump"`
+	// Longest time a value may stay unchanged before the sensor is taken
+	// for stuck, zero to disable
+	FlatlineAfter Duration `json:"flatline_after" yaml:"flatline_after"`
+	// Changes within the tolerance count as unchanged
+	FlatlineTolerance float64 `json:"flatline_tolerance" yaml:"flatline_tolerance"`
+}
+
+func (c *Channel) validateQuality() error {
+	if c.Quality == nil {
+		return nil
+	}
+
+	if c.IsDiscrete() {
+		return fmt.Errorf("discrete channel %s has no quality checks to tune", c.Name)
+	}
+
+	if c.Quality.MaxJump != nil && *c.Quality.MaxJump <= 0 {
+		return fmt.Errorf("channel %s must have a positive max jump", c.Name)
+	}
+
+	if c.Quality.FlatlineAfter < 0 || c.Quality.FlatlineTolerance < 0 {
+		return fmt.Errorf("channel %s has a negative flatline setting", c.Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,336 @@
+package channels
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"iss-telemetry-analyzer/src/calibration"
+	"iss-telemetry-analyzer/src/config"
+	"iss-telemetry-analyzer/src/derivative"
+	"iss-telemetry-analyzer/src/expr"
+	"iss-telemetry-analyzer/src/resample"
+	"iss-telemetry-analyzer/src/timestamp"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Role is the contribution of a channel to the model feature vector
+type Role string
+
+const (
+	VALUE       Role = "value"
+	CHANGE_RATE Role = "change_rate"
+	STATE_INDEX Role = "state_index" // Index of the state of a discrete channel
+	ONE_HOT     Role = "one_hot"     // One feature per state of a discrete channel
+	WINDOW      Role = "window"      // One feature per statistic of each rolling window
+	SPECTRUM    Role = "spectrum"    // Dominant frequency, centroid, entropy and band powers
+)
+
+// featureRoles is the order in which roles are laid out in the feature vector
+var featureRoles = []Role{VALUE, CHANGE_RATE, STATE_INDEX, ONE_HOT, WINDOW}
+
+// numericRoles are the default roles of the numeric channels
+var numericRoles = []Role{VALUE, CHANGE_RATE}
+
+// Channel describes a telemetry channel
+type Channel struct {
+	Name     string    `json:"name" yaml:"name"`
+	Type     ValueType `json:"type" yaml:"type"`
+	States   []string  `json:"states" yaml:"states"` // States of enum channels, in index order
+	Unit     string    `json:"unit" yaml:"unit"`
+	Min      *float64  `json:"min" yaml:"min"`
+	Max      *float64  `json:"max" yaml:"max"`
+	Features []Role    `json:"features" yaml:"features"`
+	MaxAge   Duration  `json:"max_age" yaml:"max_age"` // Zero disables staleness tracking
+	// Interpolation and longest interpolated gap when resampling, the
+	// registry defaults when empty
+	Interpolation resample.Method `json:"interpolation" yaml:"interpolation"`
+	MaxGap        Duration        `json:"max_gap" yaml:"max_gap"`
+	// Estimator of the change rate, the two-point difference when not set
+	Derivative *derivative.Settings `json:"derivative" yaml:"derivative"`
+	// Expression computing a derived channel from other channels, e.g.
+	// PRESSURE_IN - PRESSURE_OUT
+	Expression string `json:"expression" yaml:"expression"`
+	// Curve turning raw values into values in the channel unit
+	Calibration *calibration.Curve `json:"calibration" yaml:"calibration"`
+	// States each state of a discrete channel may change to. States that
+	// are not listed may change to any state.
+	AllowedTransitions map[string][]string `json:"allowed_transitions" yaml:"allowed_transitions"`
+	// Sequences of states that must never occur, oldest first
+	IllegalSequences [][]string `json:"illegal_sequences" yaml:"illegal_sequences"`
+	// Notation of the reading timestamps, detected when empty
+	TimestampFormat timestamp.Format `json:"timestamp_format" yaml:"timestamp_format"`
+	// Rolling windows summarised into features, e.g. over 30s, 5m and one orbit
+	Windows []Window `json:"windows" yaml:"windows"`
+	// Power spectrum summarised into features, not computed when not set
+	Spectrum *Spectrum `json:"spectrum" yaml:"spectrum"`
+	// Spike and flatline checks of the readings, not done when not set
+	Quality *Quality `json:"quality" yaml:"quality"`
+
+	derivation *expr.Expression
+}
+
+// Registry holds the channels the analyzer knows about, in declaration order
+type Registry struct {
+	Channels []Channel `json:"channels" yaml:"channels"`
+	// Resampling of the numeric channels, disabled when not set
+	Resampling *Resampling `json:"resampling" yaml:"resampling"`
+	// Pairs of channels whose correlation is tracked
+	Couplings []Coupling `json:"couplings" yaml:"couplings"`
+
+	byName       map[string]int
+	derivedOrder []int // Indexes of the derived channels in evaluation order
+}
+
+// defaultMaxAge is the staleness limit of the default channels. ISS Live only
+// publishes on change, so it is well above the update interval.
+const defaultMaxAge = Duration(10 * time.Minute)
+
+// FeatureSlot is one position of the feature vector
+type FeatureSlot struct {
+	Channel string
+	Role    Role
+	State   string // State of one-hot slots
+	Window  string // Statistic and window of window slots, e.g. mean_5m
+	Feature string // Spectral feature of spectrum slots, e.g. spectral_entropy
+	// Coupling of correlation slots, whose Channel is empty
+	Coupling string
+}
+
+// Default returns the registry of the three ETCS loop parameters
+func Default() *Registry {
+	registry := &Registry{
+		Channels: []Channel{
+			{Name: "FLOWRATE", Type: FLOAT, Unit: "kg/h", Features: numericRoles, MaxAge: defaultMaxAge},
+			{Name: "PRESSURE", Type: FLOAT, Unit: "kPa", Features: numericRoles, MaxAge: defaultMaxAge},
+			{Name: "TEMPERATURE", Type: FLOAT, Unit: "degC", Features: numericRoles, MaxAge: defaultMaxAge},
+		},
+	}
+
+	if err := registry.init(); err != nil {
+		panic(err)
+	}
+
+	return registry
+}
+
+// LoadFromEnv loads the registry from the location in CHANNEL_REGISTRY, or
+// returns the default registry when it is not set
+func LoadFromEnv() (*Registry, error) {
+	location := os.Getenv("CHANNEL_REGISTRY")
+
+	if location == "" {
+		return Default(), nil
+	}
+
+	return Load(location)
+}
+
+// Load reads a JSON or YAML registry from a local path or an S3 location
+func Load(location string) (*Registry, error) {
+	content, err := config.ReadSource(location)
+
+	if err != nil {
+		return nil, err
+	}
+
+	extension := strings.ToLower(filepath.Ext(location))
+
+	return Parse(content, extension == ".yaml" || extension == ".yml")
+}
+
+// Parse decodes and validates a registry definition
+func Parse(content []byte, isYAML bool) (*Registry, error) {
+	var registry Registry
+	var err error
+
+	if isYAML {
+		err = yaml.Unmarshal(content, &registry)
+	} else {
+		err = json.Unmarshal(content, &registry)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse channel registry: %w", err)
+	}
+
+	if err := registry.init(); err != nil {
+		return nil, err
+	}
+
+	return &registry, nil
+}
+
+func (r *Registry) init() error {
+	r.byName = map[string]int{}
+
+	for i := range r.Channels {
+		channel := &r.Channels[i]
+
+		if channel.Name == "" {
+			return fmt.Errorf("channel %d has no name", i)
+		}
+
+		if _, exists := r.byName[channel.Name]; exists {
+			return fmt.Errorf("channel %s is declared twice", channel.Name)
+		}
+
+		if channel.Type == "" {
+			channel.Type = FLOAT
+		}
+
+		if err := channel.validateType(); err != nil {
+			return err
+		}
+
+		if err := channel.validateCalibration(); err != nil {
+			return err
+		}
+
+		if err := channel.validateDerivative(); err != nil {
+			return err
+		}
+
+		if err := channel.validateWindows(); err != nil {
+			return err
+		}
+
+		if err := channel.validateSpectrum(); err != nil {
+			return err
+		}
+
+		if err := channel.validateQuality(); err != nil {
+			return err
+		}
+
+		format, err := timestamp.ParseFormat(string(channel.TimestampFormat))
+
+		if err != nil {
+			return fmt.Errorf("channel %s: %w", channel.Name, err)
+		}
+
+		channel.TimestampFormat = format
+
+		if channel.MaxAge < 0 {
+			return fmt.Errorf("channel %s has a negative max age", channel.Name)
+		}
+
+		if channel.Min != nil && channel.Max != nil && *channel.Min > *channel.Max {
+			return fmt.Errorf("channel %s has min greater than max", channel.Name)
+		}
+
+		r.byName[channel.Name] = i
+	}
+
+	if err := r.initResampling(); err != nil {
+		return err
+	}
+
+	if err := r.initCouplings(); err != nil {
+		return err
+	}
+
+	return r.initDerived()
+}
+
+// Get returns the channel with the given name
+func (r *Registry) Get(name string) (*Channel, bool) {
+	i, ok := r.byName[name]
+
+	if !ok {
+		return nil, false
+	}
+
+	return &r.Channels[i], true
+}
+
+// FeatureChannels returns the channels that contribute to the feature vector
+func (r *Registry) FeatureChannels() []*Channel {
+	var featureChannels []*Channel
+
+	for i := range r.Channels {
+		if len(r.Channels[i].Features) > 0 {
+			featureChannels = append(featureChannels, &r.Channels[i])
+		}
+	}
+
+	return featureChannels
+}
+
+// FeatureSlots lays out the feature vector by role: values, change rates,
+// state indexes, one-hot states and window statistics, each in channel
+// declaration order, followed by the correlations of the couplings and the
+// spectral features of each channel
+func (r *Registry) FeatureSlots() []FeatureSlot {
+	var slots []FeatureSlot
+
+	for _, role := range featureRoles {
+		for _, channel := range r.FeatureChannels() {
+			if !channel.HasFeature(role) {
+				continue
+			}
+
+			switch role {
+			case ONE_HOT:
+				for _, state := range channel.StateNames() {
+					slots = append(slots, FeatureSlot{Channel: channel.Name, Role: role, State: state})
+				}
+			case WINDOW:
+				for _, key := range channel.WindowKeys() {
+					slots = append(slots, FeatureSlot{Channel: channel.Name, Role: role, Window: key})
+				}
+			default:
+				slots = append(slots, FeatureSlot{Channel: channel.Name, Role: role})
+			}
+		}
+	}
+
+	for _, role := range couplingRoles {
+		for _, coupling := range r.Couplings {
+			if coupling.HasFeature(role) {
+				slots = append(slots, FeatureSlot{Coupling: coupling.Name, Role: role})
+			}
+		}
+	}
+
+	for _, channel := range r.FeatureChannels() {
+		if !channel.HasFeature(SPECTRUM) {
+			continue
+		}
+
+		settings := channel.SpectrumSettings()
+
+		for _, key := range settings.Keys() {
+			slots = append(slots, FeatureSlot{Channel: channel.Name, Role: SPECTRUM, Feature: key})
+		}
+	}
+
+	return slots
+}
+
+func (c *Channel) HasFeature(role Role) bool {
+	return slices.Contains(c.Features, role)
+}
+
+// IsStale reports whether a reading of the given age is too old to be used
+func (c *Channel) IsStale(age time.Duration) bool {
+	return c.MaxAge > 0 && age > time.Duration(c.MaxAge)
+}
+
+// InRange reports whether a value is within the channel's valid range
+func (c *Channel) InRange(value float64) bool {
+	if c.Min != nil && value < *c.Min {
+		return false
+	}
+
+	if c.Max != nil && value > *c.Max {
+		return false
+	}
+
+	return true
+}
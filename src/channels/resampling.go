@@ -0,0 +1,78 @@
+package channels
+
+import (
+	"fmt"
+	"time"
+
+	"iss-telemetry-analyzer/src/resample"
+)
+
+// Resampling aligns the numeric channels of each bucket on a common grid
+// before they are aggregated
+type Resampling struct {
+	Period Duration        `json:"period" yaml:"period"`   // Grid spacing, e.g. "1s"
+	Method resample.Method `json:"method" yaml:"method"`   // Default interpolation of the channels
+	MaxGap Duration        `json:"max_gap" yaml:"max_gap"` // Default max gap, zero for no limit
+}
+
+// ResampleSettings returns how a channel is resampled, falling back to the
+// registry defaults
+func (r *Registry) ResampleSettings(channel *Channel) resample.Settings {
+	settings := resample.Settings{Method: channel.Interpolation, MaxGap: time.Duration(channel.MaxGap)}
+
+	if r.Resampling == nil {
+		return settings
+	}
+
+	if settings.Method == "" {
+		settings.Method = r.Resampling.Method
+	}
+
+	if settings.MaxGap == 0 {
+		settings.MaxGap = time.Duration(r.Resampling.MaxGap)
+	}
+
+	return settings
+}
+
+func (r *Registry) initResampling() error {
+	if r.Resampling != nil {
+		if r.Resampling.Period <= 0 {
+			return fmt.Errorf("resampling period must be positive")
+		}
+
+		if r.Resampling.MaxGap < 0 {
+			return fmt.Errorf("resampling max gap cannot be negative")
+		}
+
+		method, err := resample.ParseMethod(string(r.Resampling.Method))
+
+		if err != nil {
+			return err
+		}
+
+		r.Resampling.Method = method
+	}
+
+	for i := range r.Channels {
+		channel := &r.Channels[i]
+
+		if channel.MaxGap < 0 {
+			return fmt.Errorf("channel %s has a negative max gap", channel.Name)
+		}
+
+		if channel.Interpolation == "" {
+			continue
+		}
+
+		method, err := resample.ParseMethod(string(channel.Interpolation))
+
+		if err != nil {
+			return fmt.Errorf("channel %s: %w", channel.Name, err)
+		}
+
+		channel.Interpolation = method
+	}
+
+	return nil
+}
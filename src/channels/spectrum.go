@@ -0,0 +1,71 @@
+package channels
+
+import (
+	"fmt"
+	"time"
+
+	"iss-telemetry-analyzer/src/spectrum"
+)
+
+// defaultSpectrumWindow is the length analysed by spectra that set none
+const defaultSpectrumWindow = Duration(10 * time.Minute)
+
+// Spectrum is the power spectrum of the bucket values of a numeric channel
+// over a sliding window. Bucket values are too far apart to see anything
+// faster than half the bucket rate.
+type Spectrum struct {
+	Window  Duration        `json:"window" yaml:"window"`
+	Segment int             `json:"segment" yaml:"segment"` // Values per Welch segment, a power of two
+	Bands   []spectrum.Band `json:"bands" yaml:"bands"`
+}
+
+// SpectrumSettings returns how the spectrum of the channel is estimated
+func (c *Channel) SpectrumSettings() spectrum.Settings {
+	if c.Spectrum == nil {
+		return spectrum.Settings{}
+	}
+
+	return spectrum.Settings{Segment: c.Spectrum.Segment, Bands: c.Spectrum.Bands}
+}
+
+// SpectrumWindow returns the length of the window the spectrum is estimated
+// over, zero when the channel has no spectrum
+func (c *Channel) SpectrumWindow() time.Duration {
+	if c.Spectrum == nil {
+		return 0
+	}
+
+	return time.Duration(c.Spectrum.Window)
+}
+
+func (c *Channel) validateSpectrum() error {
+	if c.Spectrum == nil {
+		if c.HasFeature(SPECTRUM) {
+			return fmt.Errorf("channel %s has spectral features but no spectrum", c.Name)
+		}
+
+		return nil
+	}
+
+	if c.IsDiscrete() {
+		return fmt.Errorf("discrete channel %s has no spectrum", c.Name)
+	}
+
+	if c.Spectrum.Window == 0 {
+		c.Spectrum.Window = defaultSpectrumWindow
+	}
+
+	if c.Spectrum.Window < 0 {
+		return fmt.Errorf("channel %s has a negative spectrum window", c.Name)
+	}
+
+	settings := c.SpectrumSettings()
+
+	if err := settings.Validate(); err != nil {
+		return fmt.Errorf("channel %s: %w", c.Name, err)
+	}
+
+	c.Spectrum.Segment = settings.Segment
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package channels
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ValueType is the type of the values reported by a channel
+type ValueType string
+
+const (
+	FLOAT   ValueType = "float"
+	INTEGER ValueType = "integer"
+	BOOLEAN ValueType = "boolean"
+	ENUM    ValueType = "enum" // One of the channel's declared states
+)
+
+// MaxSequenceLength bounds the illegal sequences a channel may declare
+const MaxSequenceLength = 16
+
+// booleanStates are the states of boolean channels, in index order
+var booleanStates = []string{"OFF", "ON"}
+
+var booleanValues = map[string]bool{
+	"0": false, "false": false, "off": false, "no": false,
+	"1": true, "true": true, "on": true, "yes": true,
+}
+
+// Value is a parsed reading. Discrete channels report a state, with its
+// index in the channel's states as the number.
+type Value struct {
+	Number float64
+	State  string
+}
+
+// IsDiscrete reports whether the channel reports states rather than numbers
+func (c *Channel) IsDiscrete() bool {
+	return c.Type == BOOLEAN || c.Type == ENUM
+}
+
+// StateNames returns the states of a discrete channel in index order
+func (c *Channel) StateNames() []string {
+	switch c.Type {
+	case BOOLEAN:
+		return booleanStates
+	case ENUM:
+		return c.States
+	default:
+		return nil
+	}
+}
+
+// ParseValue reads a raw value according to the channel type. Enum values
+// match state names regardless of case, or give a state index.
+func (c *Channel) ParseValue(raw string) (Value, error) {
+	raw = strings.TrimSpace(raw)
+
+	switch c.Type {
+	case BOOLEAN:
+		on, ok := booleanValues[strings.ToLower(raw)]
+
+		if !ok {
+			return Value{}, fmt.Errorf("invalid boolean value %q", raw)
+		}
+
+		if on {
+			return Value{Number: 1, State: booleanStates[1]}, nil
+		}
+
+		return Value{Number: 0, State: booleanStates[0]}, nil
+
+	case ENUM:
+		for i, state := range c.States {
+			if strings.EqualFold(state, raw) {
+				return Value{Number: float64(i), State: state}, nil
+			}
+		}
+
+		if index, err := strconv.Atoi(raw); err == nil && index >= 0 && index < len(c.States) {
+			return Value{Number: float64(index), State: c.States[index]}, nil
+		}
+
+		return Value{}, fmt.Errorf("value %q is not one of the states %s", raw, strings.Join(c.States, ", "))
+
+	case INTEGER:
+		number, err := strconv.ParseFloat(raw, 64)
+
+		if err != nil || number != math.Trunc(number) {
+			return Value{}, fmt.Errorf("invalid integer value %q", raw)
+		}
+
+		return Value{Number: number}, nil
+
+	default:
+		number, err := strconv.ParseFloat(raw, 64)
+
+		if err != nil {
+			return Value{}, fmt.Errorf("invalid float value %q", raw)
+		}
+
+		return Value{Number: number}, nil
+	}
+}
+
+// validateType checks the states and feature roles against the value type
+func (c *Channel) validateType() error {
+	switch c.Type {
+	case FLOAT, INTEGER, BOOLEAN:
+		if len(c.States) > 0 {
+			return fmt.Errorf("channel %s declares states but is not an enum", c.Name)
+		}
+	case ENUM:
+		if len(c.States) == 0 {
+			return fmt.Errorf("enum channel %s declares no states", c.Name)
+		}
+
+		for i, state := range c.States {
+			if state == "" || slices.IndexFunc(c.States[:i], func(other string) bool { return strings.EqualFold(other, state) }) >= 0 {
+				return fmt.Errorf("enum channel %s has an empty or duplicate state %q", c.Name, state)
+			}
+		}
+	default:
+		return fmt.Errorf("channel %s has unsupported type %s", c.Name, c.Type)
+	}
+
+	for _, role := range c.Features {
+		discreteRole := role == STATE_INDEX || role == ONE_HOT
+
+		// Spectral features are laid out after the other roles
+		if !slices.Contains(featureRoles, role) && role != SPECTRUM {
+			return fmt.Errorf("channel %s has unknown feature role %s", c.Name, role)
+		}
+
+		if discreteRole != c.IsDiscrete() {
+			return fmt.Errorf("feature role %s does not apply to %s channel %s", role, c.Type, c.Name)
+		}
+	}
+
+	if c.IsDiscrete() && (c.Min != nil || c.Max != nil) {
+		return fmt.Errorf("discrete channel %s cannot declare a valid range", c.Name)
+	}
+
+	return c.validateTransitions()
+}
+
+// validateTransitions checks that transition rules only name known states
+func (c *Channel) validateTransitions() error {
+	if len(c.AllowedTransitions) == 0 && len(c.IllegalSequences) == 0 {
+		return nil
+	}
+
+	if !c.IsDiscrete() {
+		return fmt.Errorf("channel %s declares transition rules but is not discrete", c.Name)
+	}
+
+	states := c.StateNames()
+
+	for from, allowed := range c.AllowedTransitions {
+		for _, state := range append([]string{from}, allowed...) {
+			if !slices.Contains(states, state) {
+				return fmt.Errorf("allowed transitions of channel %s name unknown state %q", c.Name, state)
+			}
+		}
+	}
+
+	for _, sequence := range c.IllegalSequences {
+		if len(sequence) < 2 || len(sequence) > MaxSequenceLength {
+			return fmt.Errorf("illegal sequences of channel %s need between 2 and %d states", c.Name, MaxSequenceLength)
+		}
+
+		for _, state := range sequence {
+			if !slices.Contains(states, state) {
+				return fmt.Errorf("illegal sequences of channel %s name unknown state %q", c.Name, state)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package channels
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"iss-telemetry-analyzer/src/window"
+)
+
+// Window is a rolling horizon over which statistics of a numeric channel are
+// computed from its bucket values
+type Window struct {
+	// Names the features of the window, e.g. 5m in PRESSURE.mean_5m. Defaults
+	// to the length.
+	Name string `json:"name" yaml:"name"`
+	// Length of the horizon, one orbit when the window is named orbit
+	Length     Duration           `json:"length" yaml:"length"`
+	Statistics []window.Statistic `json:"statistics" yaml:"statistics"` // Every statistic when empty
+}
+
+// Horizons returns the rolling windows of the channel
+func (c *Channel) Horizons() []window.Horizon {
+	horizons := make([]window.Horizon, len(c.Windows))
+
+	for i, w := range c.Windows {
+		horizons[i] = window.Horizon{Name: w.Name, Length: time.Duration(w.Length), Statistics: w.Statistics}
+	}
+
+	return horizons
+}
+
+// WindowKeys lists the window statistics of the channel by horizon, in the
+// order they are laid out in the feature vector
+func (c *Channel) WindowKeys() []string {
+	var keys []string
+
+	for _, w := range c.Windows {
+		for _, statistic := range w.Statistics {
+			keys = append(keys, window.Key(statistic, w.Name))
+		}
+	}
+
+	return keys
+}
+
+// LongestWindow returns the length of the longest rolling window, zero when
+// the channel has none
+func (c *Channel) LongestWindow() time.Duration {
+	var longest time.Duration
+
+	for _, w := range c.Windows {
+		longest = max(longest, time.Duration(w.Length))
+	}
+
+	return longest
+}
+
+func (c *Channel) validateWindows() error {
+	if len(c.Windows) == 0 {
+		if c.HasFeature(WINDOW) {
+			return fmt.Errorf("channel %s has window features but no windows", c.Name)
+		}
+
+		return nil
+	}
+
+	if c.IsDiscrete() {
+		return fmt.Errorf("discrete channel %s cannot have rolling windows", c.Name)
+	}
+
+	names := map[string]bool{}
+
+	for i := range c.Windows {
+		w := &c.Windows[i]
+
+		if w.Length == 0 && w.Name == "orbit" {
+			w.Length = Duration(window.OrbitPeriod)
+		}
+
+		if w.Length < Duration(time.Second) {
+			return fmt.Errorf("window %d of channel %s must be at least one second long", i, c.Name)
+		}
+
+		if w.Name == "" {
+			w.Name = compactDuration(time.Duration(w.Length))
+		}
+
+		if strings.ContainsAny(w.Name, "._ ") {
+			return fmt.Errorf("window %q of channel %s cannot contain dots, underscores or spaces", w.Name, c.Name)
+		}
+
+		if names[w.Name] {
+			return fmt.Errorf("channel %s declares window %s twice", c.Name, w.Name)
+		}
+
+		names[w.Name] = true
+
+		if len(w.Statistics) == 0 {
+			w.Statistics = slices.Clone(window.Statistics)
+		}
+
+		for j, statistic := range w.Statistics {
+			parsed, err := window.ParseStatistic(string(statistic))
+
+			if err != nil {
+				return fmt.Errorf("channel %s: %w", c.Name, err)
+			}
+
+			if slices.Contains(w.Statistics[:j], parsed) {
+				return fmt.Errorf("window %s of channel %s lists %s twice", w.Name, c.Name, parsed)
+			}
+
+			w.Statistics[j] = parsed
+		}
+	}
+
+	return nil
+}
+
+// compactDuration writes a duration in its largest whole unit, e.g. 5m
+func compactDuration(d time.Duration) string {
+	switch {
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	default:
+		return fmt.Sprintf("%ds", d/time.Second)
+	}
+}
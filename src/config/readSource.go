@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// ReadSource reads a configuration file from a local path or from an
+// s3://bucket/key location
+func ReadSource(location string) ([]byte, error) {
+	if !strings.HasPrefix(location, "s3://") {
+		content, err := os.ReadFile(location)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", location, err)
+		}
+
+		return content, nil
+	}
+
+	bucketName, key, found := strings.Cut(strings.TrimPrefix(location, "s3://"), "/")
+
+	if !found || bucketName == "" || key == "" {
+		return nil, fmt.Errorf("invalid S3 location: %s", location)
+	}
+
+	sess := session.Must(session.NewSession(&aws.Config{
+		Region: aws.String("eu-west-1"),
+	}))
+	svc := s3.New(sess)
+
+	result, err := svc.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", location, err)
+	}
+	defer result.Body.Close()
+
+	content, err := io.ReadAll(result.Body)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", location, err)
+	}
+
+	return content, nil
+}
@@ -0,0 +1,133 @@
+package correlation
+
+import (
+	"math"
+	"time"
+
+	"iss-telemetry-analyzer/src/window"
+)
+
+// minPairs is the fewest aligned values a correlation is computed from
+const minPairs = 3
+
+// Result is the correlation of two channels over a sliding window
+type Result struct {
+	Pearson float64
+	// Strongest correlation found by shifting the second channel, and the
+	// shift in seconds. A positive lag means the second channel follows.
+	CrossCorrelation float64
+	Lag              float64
+	Pairs            int // Aligned values the Pearson correlation was computed from
+}
+
+// Compute correlates the bucket values of two channels within the window of
+// their newest point, shifting the second channel by up to maxLag in steps
+// of one bucket. It returns false when too few values are aligned.
+func Compute(first []window.Point, second []window.Point, length time.Duration, maxLag time.Duration, step time.Duration) (Result, bool) {
+	if len(first) == 0 || len(second) == 0 {
+		return Result{}, false
+	}
+
+	newest := max(first[len(first)-1].Time, second[len(second)-1].Time)
+	start := newest - int64(length/time.Second)
+	stepSeconds := int64(step / time.Second)
+
+	firstValues := within(first, start)
+	secondValues := map[int64]float64{}
+
+	for _, point := range within(second, start) {
+		secondValues[point.Time] = point.Value
+	}
+
+	x, y := align(firstValues, secondValues, 0)
+	pearson, ok := Pearson(x, y)
+
+	if !ok {
+		return Result{}, false
+	}
+
+	result := Result{Pearson: pearson, CrossCorrelation: pearson, Pairs: len(x)}
+
+	if stepSeconds <= 0 {
+		return result, true
+	}
+
+	maxShift := int64(maxLag / step)
+
+	for shift := -maxShift; shift <= maxShift; shift++ {
+		if shift == 0 {
+			continue
+		}
+
+		x, y := align(firstValues, secondValues, shift*stepSeconds)
+		shifted, ok := Pearson(x, y)
+
+		if ok && math.Abs(shifted) > math.Abs(result.CrossCorrelation) {
+			result.CrossCorrelation = shifted
+			result.Lag = float64(shift * stepSeconds)
+		}
+	}
+
+	return result, true
+}
+
+// Pearson computes the correlation coefficient of two series of the same
+// length. Constant series are uncorrelated.
+func Pearson(x []float64, y []float64) (float64, bool) {
+	if len(x) < minPairs || len(x) != len(y) {
+		return 0, false
+	}
+
+	n := float64(len(x))
+	var meanX, meanY float64
+
+	for i := range x {
+		meanX += x[i]
+		meanY += y[i]
+	}
+
+	meanX /= n
+	meanY /= n
+
+	var covariance, varianceX, varianceY float64
+
+	for i := range x {
+		dx := x[i] - meanX
+		dy := y[i] - meanY
+		covariance += dx * dy
+		varianceX += dx * dx
+		varianceY += dy * dy
+	}
+
+	if varianceX == 0 || varianceY == 0 {
+		return 0, true
+	}
+
+	return covariance / math.Sqrt(varianceX*varianceY), true
+}
+
+// within returns the points newer than start
+func within(points []window.Point, start int64) []window.Point {
+	for i, point := range points {
+		if point.Time > start {
+			return points[i:]
+		}
+	}
+
+	return nil
+}
+
+// align pairs each value of the first channel, in time order, with the value
+// of the second channel lag seconds later
+func align(first []window.Point, second map[int64]float64, lag int64) ([]float64, []float64) {
+	var x, y []float64
+
+	for _, point := range first {
+		if other, ok := second[point.Time+lag]; ok {
+			x = append(x, point.Value)
+			y = append(y, other)
+		}
+	}
+
+	return x, y
+}
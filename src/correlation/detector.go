@@ -0,0 +1,132 @@
+package correlation
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"iss-telemetry-analyzer/src/channels"
+	"iss-telemetry-analyzer/src/types"
+	"iss-telemetry-analyzer/src/utils"
+)
+
+// minBaselineCount is the number of buckets learned before a coupling is
+// checked, ten minutes of buckets
+const minBaselineCount = 120
+
+// minDeviation keeps a very steady coupling from being flagged for noise
+const minDeviation = 0.05
+
+// Baseline keeps a running mean and variance of the strength of a coupling,
+// the absolute best-lag correlation. It is stored with the bucket cursor.
+type Baseline struct {
+	Count int     `dynamodbav:"Count"`
+	Mean  float64 `dynamodbav:"Mean"`
+	M2    float64 `dynamodbav:"M2"` // Sum of squared deviations from the mean
+}
+
+// Detector flags couplings whose correlation fell well below the usual
+type Detector struct {
+	registry *channels.Registry
+}
+
+func NewDetector(registry *channels.Registry) *Detector {
+	return &Detector{registry: registry}
+}
+
+// Observe checks the couplings of a bucket against their baselines, then
+// learns from them. Baselines are created as needed.
+func (d *Detector) Observe(baselines map[string]*Baseline, processedData *types.ProcessedData) []types.DecouplingAnomaly {
+	var anomalies []types.DecouplingAnomaly
+
+	for _, coupling := range d.registry.Couplings {
+		couplingValue, ok := processedData.Couplings[coupling.Name]
+
+		if !ok {
+			continue
+		}
+
+		baseline, ok := baselines[coupling.Name]
+
+		if !ok {
+			baseline = &Baseline{}
+			baselines[coupling.Name] = baseline
+		}
+
+		strength := math.Abs(couplingValue.CrossCorrelation)
+
+		if anomaly, ok := baseline.check(strength); ok {
+			anomaly.Coupling = coupling.Name
+			anomaly.Timestamp = processedData.Timestamp
+			anomaly.Correlation = couplingValue.CrossCorrelation
+			anomalies = append(anomalies, anomaly)
+		}
+
+		baseline.add(strength)
+	}
+
+	return anomalies
+}
+
+// check compares the strength of a coupling with the learned strength, the
+// same way anomaly scores are compared with recent scores. Only a weaker
+// coupling is an anomaly.
+func (b *Baseline) check(strength float64) (types.DecouplingAnomaly, bool) {
+	if b.Count < minBaselineCount || strength >= b.Mean {
+		return types.DecouplingAnomaly{}, false
+	}
+
+	deviation := math.Max(b.standardDeviation(), minDeviation)
+	level := utils.ComputeAnomalyLevel(strength, deviation, b.Mean)
+
+	if level == utils.NO_ANOMALY {
+		return types.DecouplingAnomaly{}, false
+	}
+
+	return types.DecouplingAnomaly{Expected: b.Mean, Level: level.String()}, true
+}
+
+func (b *Baseline) add(strength float64) {
+	b.Count++
+	delta := strength - b.Mean
+	b.Mean += delta / float64(b.Count)
+	b.M2 += delta * (strength - b.Mean)
+}
+
+func (b *Baseline) standardDeviation() float64 {
+	if b.Count < 2 {
+		return 0
+	}
+
+	return math.Sqrt(b.M2 / float64(b.Count-1))
+}
+
+// Level is the highest level of the anomalies
+func Level(anomalies []types.DecouplingAnomaly) utils.AnomalyLevel {
+	level := utils.NO_ANOMALY
+
+	for _, anomaly := range anomalies {
+		if anomalyLevel, ok := utils.ParseAnomalyLevel(anomaly.Level); ok {
+			level = utils.MaxLevel(level, anomalyLevel)
+		}
+	}
+
+	return level
+}
+
+// Emit logs a decoupling anomaly
+func Emit(anomaly types.DecouplingAnomaly) {
+	logData := map[string]interface{}{
+		"log_type": "decoupling_anomaly",
+		"anomaly":  anomaly,
+	}
+
+	logDataBytes, err := json.Marshal(logData)
+
+	if err != nil {
+		fmt.Printf("Error marshaling decoupling anomaly: %v\n", err)
+		return
+	}
+
+	fmt.Println(string(logDataBytes))
+}
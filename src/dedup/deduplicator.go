@@ -0,0 +1,142 @@
+package dedup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"iss-telemetry-analyzer/src/dynamo"
+	"iss-telemetry-analyzer/src/types"
+)
+
+// KeyMode selects how records are identified
+type KeyMode string
+
+const (
+	SEQUENCE KeyMode = "sequence" // Source record ID, e.g. Kinesis shard ID and sequence number
+	CONTENT  KeyMode = "content"  // Hash of name, timestamp and value
+)
+
+// defaultTTL is how long processed records are remembered by default
+const defaultTTL = 24 * time.Hour
+
+// maxLease bounds the lease of a claim when the context has no deadline.
+// It matches the longest Lambda timeout.
+const maxLease = 15 * time.Minute
+
+// leaseMargin outlasts the deadline of the invocation holding a lease
+const leaseMargin = 10 * time.Second
+
+// ErrInProgress is returned when a key is leased by a running invocation.
+// The record is retried once the invocation completes it or dies.
+var ErrInProgress = errors.New("record is being processed")
+
+// Store remembers claimed record keys. A claim is a lease that only lasts as
+// long as the invocation that took it, and becomes a mark lasting the TTL
+// once the record is completed.
+type Store interface {
+	// Claim leases a key. It returns false if the key was completed and has
+	// not expired, and ErrInProgress while another lease on it runs.
+	Claim(ctx context.Context, key string, leaseUntil time.Time) (bool, error)
+	// Complete keeps a claimed key until it expires
+	Complete(ctx context.Context, key string, expiresAt time.Time) error
+	Release(ctx context.Context, key string) error
+}
+
+// Deduplicator makes record processing idempotent across retries
+type Deduplicator struct {
+	store   Store
+	keyMode KeyMode
+	ttl     time.Duration
+}
+
+func New(store Store, keyMode KeyMode, ttl time.Duration) *Deduplicator {
+	return &Deduplicator{store: store, keyMode: keyMode, ttl: ttl}
+}
+
+// NewFromEnv configures deduplication from DEDUP_STORE ("dynamodb" or
+// "memory"), DEDUP_TABLE (ProcessedRecords by default), DEDUP_KEY
+// ("sequence" or "content") and DEDUP_TTL
+func NewFromEnv() (*Deduplicator, error) {
+	keyMode := KeyMode(os.Getenv("DEDUP_KEY"))
+
+	switch keyMode {
+	case "":
+		keyMode = SEQUENCE
+	case SEQUENCE, CONTENT:
+	default:
+		return nil, fmt.Errorf("invalid DEDUP_KEY %q", keyMode)
+	}
+
+	ttl := defaultTTL
+
+	if value := os.Getenv("DEDUP_TTL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid DEDUP_TTL %q", value)
+		}
+
+		ttl = parsed
+	}
+
+	var store Store
+
+	if os.Getenv("DEDUP_STORE") == "memory" {
+		store = NewMemoryStore()
+	} else {
+		tableName := os.Getenv("DEDUP_TABLE")
+
+		if tableName == "" {
+			tableName = "ProcessedRecords"
+		}
+
+		store = NewDynamoStore(dynamo.GetDynamoDBClient(), tableName)
+	}
+
+	return New(store, keyMode, ttl), nil
+}
+
+// Key identifies a reading by the ID of its source record or by its content.
+// Kinesis record IDs are event IDs, "<shard ID>:<sequence number>".
+func (d *Deduplicator) Key(recordID string, data types.TelemetryData) string {
+	if d.keyMode == CONTENT {
+		hash := sha256.Sum256([]byte(data.Name + "\x00" + data.Timestamp + "\x00" + data.Value))
+		return "content#" + hex.EncodeToString(hash[:])
+	}
+
+	return "sequence#" + recordID
+}
+
+// PacketKey identifies a CCSDS packet by its APID, sequence count and time,
+// whatever record delivered it
+func (d *Deduplicator) PacketKey(sequence types.PacketSequence) string {
+	return fmt.Sprintf("packet#%d#%d#%s", sequence.APID, sequence.SequenceCount, sequence.Time)
+}
+
+// Claim leases a key until the invocation times out, so that a record lost
+// with a crashed invocation is processed again. It returns false for
+// duplicates, which must not be processed.
+func (d *Deduplicator) Claim(ctx context.Context, key string) (bool, error) {
+	leaseUntil := time.Now().Add(maxLease)
+
+	if deadline, ok := ctx.Deadline(); ok {
+		leaseUntil = deadline.Add(leaseMargin)
+	}
+
+	return d.store.Claim(ctx, key, leaseUntil)
+}
+
+// Complete marks a claimed key as processed for the TTL
+func (d *Deduplicator) Complete(ctx context.Context, key string) error {
+	return d.store.Complete(ctx, key, time.Now().Add(d.ttl))
+}
+
+// Release forgets a key so a failed record can be processed on retry
+func (d *Deduplicator) Release(ctx context.Context, key string) error {
+	return d.store.Release(ctx, key)
+}
@@ -0,0 +1,102 @@
+package dedup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Status of a key in the table
+const (
+	statusInProgress = "in_progress"
+	statusComplete   = "complete"
+)
+
+// DynamoStore keeps claimed keys in a DynamoDB table whose TTL attribute is
+// ExpiresAt (epoch seconds)
+type DynamoStore struct {
+	client    *dynamodb.DynamoDB
+	tableName string
+}
+
+func NewDynamoStore(client *dynamodb.DynamoDB, tableName string) *DynamoStore {
+	return &DynamoStore{client: client, tableName: tableName}
+}
+
+func (d *DynamoStore) Claim(ctx context.Context, key string, leaseUntil time.Time) (bool, error) {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+
+	// DynamoDB deletes expired items lazily, so treat them as absent. An
+	// expired lease was left by an invocation that died.
+	_, err := d.client.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(d.tableName),
+		Item: map[string]*dynamodb.AttributeValue{
+			"RecordKey": {S: aws.String(key)},
+			"ExpiresAt": {N: aws.String(strconv.FormatInt(leaseUntil.Unix(), 10))},
+			"Status":    {S: aws.String(statusInProgress)},
+		},
+		ConditionExpression: aws.String("attribute_not_exists(RecordKey) OR ExpiresAt < :now"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":now": {N: aws.String(now)},
+		},
+		ReturnValuesOnConditionCheckFailure: aws.String(dynamodb.ReturnValuesOnConditionCheckFailureAllOld),
+	})
+
+	var conditionErr *dynamodb.ConditionalCheckFailedException
+
+	if errors.As(err, &conditionErr) {
+		// Keys claimed before leases existed have no status and are complete
+		if status, ok := conditionErr.Item["Status"]; ok && aws.StringValue(status.S) == statusInProgress {
+			return false, fmt.Errorf("record %s: %w", key, ErrInProgress)
+		}
+
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed to claim record %s: %w", key, err)
+	}
+
+	return true, nil
+}
+
+func (d *DynamoStore) Complete(ctx context.Context, key string, expiresAt time.Time) error {
+	_, err := d.client.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(d.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"RecordKey": {S: aws.String(key)},
+		},
+		UpdateExpression:         aws.String("SET ExpiresAt = :expiresAt, #status = :complete"),
+		ExpressionAttributeNames: map[string]*string{"#status": aws.String("Status")},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":expiresAt": {N: aws.String(strconv.FormatInt(expiresAt.Unix(), 10))},
+			":complete":  {S: aws.String(statusComplete)},
+		},
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to complete record %s: %w", key, err)
+	}
+
+	return nil
+}
+
+func (d *DynamoStore) Release(ctx context.Context, key string) error {
+	_, err := d.client.DeleteItemWithContext(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(d.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"RecordKey": {S: aws.String(key)},
+		},
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to release record %s: %w", key, err)
+	}
+
+	return nil
+}
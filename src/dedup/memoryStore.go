@@ -0,0 +1,104 @@
+package dedup
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MemoryStore remembers keys within a warm Lambda instance only
+type MemoryStore struct {
+	mu       sync.Mutex
+	keys     map[string]memoryKey
+	expiries expiryQueue
+}
+
+// memoryKey is a claimed key, leased until completed
+type memoryKey struct {
+	expiresAt time.Time
+	complete  bool
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{keys: map[string]memoryKey{}}
+}
+
+func (m *MemoryStore) Claim(ctx context.Context, key string, leaseUntil time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+
+	// Drop expired keys so the map does not grow without bound
+	for len(m.expiries) > 0 && !m.expiries[0].expiresAt.After(now) {
+		expired := heap.Pop(&m.expiries).(claim)
+
+		// The key may have been released or claimed again since
+		if stored, ok := m.keys[expired.key]; ok && stored.expiresAt.Equal(expired.expiresAt) {
+			delete(m.keys, expired.key)
+		}
+	}
+
+	if stored, ok := m.keys[key]; ok {
+		if !stored.complete {
+			return false, fmt.Errorf("record %s: %w", key, ErrInProgress)
+		}
+
+		return false, nil
+	}
+
+	m.set(key, memoryKey{expiresAt: leaseUntil})
+
+	return true, nil
+}
+
+func (m *MemoryStore) Complete(ctx context.Context, key string, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.set(key, memoryKey{expiresAt: expiresAt, complete: true})
+
+	return nil
+}
+
+func (m *MemoryStore) set(key string, stored memoryKey) {
+	m.keys[key] = stored
+	heap.Push(&m.expiries, claim{key: key, expiresAt: stored.expiresAt})
+}
+
+func (m *MemoryStore) Release(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Its queue entry is skipped when it expires
+	delete(m.keys, key)
+
+	return nil
+}
+
+// claim is a key waiting in the expiry queue
+type claim struct {
+	key       string
+	expiresAt time.Time
+}
+
+// expiryQueue orders claims by expiry, the earliest first
+type expiryQueue []claim
+
+func (q expiryQueue) Len() int           { return len(q) }
+func (q expiryQueue) Less(i, j int) bool { return q[i].expiresAt.Before(q[j].expiresAt) }
+func (q expiryQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *expiryQueue) Push(x interface{}) {
+	*q = append(*q, x.(claim))
+}
+
+func (q *expiryQueue) Pop() interface{} {
+	old := *q
+	last := old[len(old)-1]
+	*q = old[:len(old)-1]
+
+	return last
+}
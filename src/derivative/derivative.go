@@ -0,0 +1,241 @@
+package derivative
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Method is how the rate of change at the newest point is estimated
+type Method string
+
+const (
+	DIFFERENCE          Method = "difference"          // Between the two newest points
+	SMOOTHED_DIFFERENCE Method = "smoothed_difference" // Exponentially weighted average of the differences in the window
+	REGRESSION          Method = "regression"          // Least-squares slope over the window
+	SAVITZKY_GOLAY      Method = "savitzky_golay"      // Derivative of a least-squares polynomial over the window
+)
+
+// Defaults of the optional settings
+const (
+	defaultWindow    = 5
+	defaultOrder     = 2
+	defaultSmoothing = 0.5
+	maxWindow        = 64
+)
+
+// Point is a value of a channel at a point in time
+type Point struct {
+	Time  time.Time `dynamodbav:"Time"`
+	Value float64   `dynamodbav:"Value"`
+}
+
+// Settings select and tune the estimator of a channel
+type Settings struct {
+	Method    Method  `json:"method" yaml:"method"`
+	Window    int     `json:"window" yaml:"window"`       // Newest points used, including the current one
+	Order     int     `json:"order" yaml:"order"`         // Polynomial order of Savitzky-Golay
+	Smoothing float64 `json:"smoothing" yaml:"smoothing"` // Weight of the newest difference in the smoothed difference, in (0, 1]
+}
+
+// DefaultSettings is the two-point difference
+var DefaultSettings = Settings{Method: DIFFERENCE, Window: 2}
+
+// Validate checks the settings and fills in the defaults
+func (s *Settings) Validate() error {
+	s.Method = Method(strings.ToLower(string(s.Method)))
+
+	switch s.Method {
+	case "", DIFFERENCE:
+		s.Method = DIFFERENCE
+		s.Window = 2
+	case SMOOTHED_DIFFERENCE, REGRESSION, SAVITZKY_GOLAY:
+	default:
+		return fmt.Errorf("unknown derivative method %q", s.Method)
+	}
+
+	if s.Window == 0 {
+		s.Window = defaultWindow
+	}
+
+	if s.Window < 2 || s.Window > maxWindow {
+		return fmt.Errorf("derivative window must be between 2 and %d points", maxWindow)
+	}
+
+	if s.Method == SAVITZKY_GOLAY {
+		if s.Order == 0 {
+			s.Order = defaultOrder
+		}
+
+		if s.Order < 1 || s.Order >= s.Window {
+			return fmt.Errorf("Savitzky-Golay order must be at least 1 and below the window of %d points", s.Window)
+		}
+	}
+
+	if s.Method == SMOOTHED_DIFFERENCE {
+		if s.Smoothing == 0 {
+			s.Smoothing = defaultSmoothing
+		}
+
+		if s.Smoothing < 0 || s.Smoothing > 1 {
+			return errors.New("derivative smoothing must be in (0, 1]")
+		}
+	}
+
+	return nil
+}
+
+// Estimate returns the rate of change per second at the newest point. The
+// points are ordered by time first, whatever order they are given in, and
+// only the newest points of the window are used. Fewer than two points
+// give a rate of zero.
+func Estimate(points []Point, settings Settings) float64 {
+	window := Window(points, settings.Window)
+
+	if len(window) < 2 {
+		return 0
+	}
+
+	switch settings.Method {
+	case SMOOTHED_DIFFERENCE:
+		return smoothedDifference(window, settings.Smoothing)
+	case REGRESSION:
+		return polynomialDerivative(window, 1)
+	case SAVITZKY_GOLAY:
+		return polynomialDerivative(window, settings.Order)
+	default:
+		return difference(window[len(window)-2], window[len(window)-1])
+	}
+}
+
+// Window returns the newest points in time order, keeping the last of
+// points with the same time
+func Window(points []Point, size int) []Point {
+	sorted := make([]Point, len(points))
+	copy(sorted, points)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	var unique []Point
+
+	for _, point := range sorted {
+		if len(unique) > 0 && unique[len(unique)-1].Time.Equal(point.Time) {
+			unique[len(unique)-1] = point
+			continue
+		}
+
+		unique = append(unique, point)
+	}
+
+	if size > 0 && len(unique) > size {
+		unique = unique[len(unique)-size:]
+	}
+
+	return unique
+}
+
+// difference is the slope from an older to a newer point
+func difference(older Point, newer Point) float64 {
+	elapsed := newer.Time.Sub(older.Time).Seconds()
+
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return (newer.Value - older.Value) / elapsed
+}
+
+func smoothedDifference(window []Point, smoothing float64) float64 {
+	rate := difference(window[0], window[1])
+
+	for i := 2; i < len(window); i++ {
+		rate = smoothing*difference(window[i-1], window[i]) + (1-smoothing)*rate
+	}
+
+	return rate
+}
+
+// polynomialDerivative fits a least-squares polynomial of the given order
+// to the window, with time measured from the newest point, and returns its
+// slope there. Order 1 is the regression slope; higher orders are the
+// Savitzky-Golay end-point derivative, which also works for irregular
+// sampling. Windows too short for the order fall back to lower orders.
+func polynomialDerivative(window []Point, order int) float64 {
+	order = min(order, len(window)-1)
+	newest := window[len(window)-1].Time
+	size := order + 1
+
+	// Normal equations of the fit: (XᵀX) c = Xᵀy
+	matrix := make([][]float64, size)
+
+	for i := range matrix {
+		matrix[i] = make([]float64, size+1)
+	}
+
+	for _, point := range window {
+		t := point.Time.Sub(newest).Seconds()
+
+		for row := 0; row < size; row++ {
+			for column := 0; column < size; column++ {
+				matrix[row][column] += math.Pow(t, float64(row+column))
+			}
+
+			matrix[row][size] += point.Value * math.Pow(t, float64(row))
+		}
+	}
+
+	coefficients, ok := solve(matrix)
+
+	if !ok {
+		return difference(window[len(window)-2], window[len(window)-1])
+	}
+
+	// The derivative at t = 0 is the linear coefficient
+	return coefficients[1]
+}
+
+// solve solves an augmented linear system by Gaussian elimination with
+// partial pivoting
+func solve(matrix [][]float64) ([]float64, bool) {
+	size := len(matrix)
+
+	for column := 0; column < size; column++ {
+		pivot := column
+
+		for row := column + 1; row < size; row++ {
+			if math.Abs(matrix[row][column]) > math.Abs(matrix[pivot][column]) {
+				pivot = row
+			}
+		}
+
+		if math.Abs(matrix[pivot][column]) < 1e-12 {
+			return nil, false
+		}
+
+		matrix[column], matrix[pivot] = matrix[pivot], matrix[column]
+
+		for row := column + 1; row < size; row++ {
+			factor := matrix[row][column] / matrix[column][column]
+
+			for k := column; k <= size; k++ {
+				matrix[row][k] -= factor * matrix[column][k]
+			}
+		}
+	}
+
+	solution := make([]float64, size)
+
+	for row := size - 1; row >= 0; row-- {
+		sum := matrix[row][size]
+
+		for k := row + 1; k < size; k++ {
+			sum -= matrix[row][k] * solution[k]
+		}
+
+		solution[row] = sum / matrix[row][row]
+	}
+
+	return solution, true
+}
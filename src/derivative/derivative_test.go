@@ -0,0 +1,131 @@
+package derivative
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// sample evaluates a signal every step seconds, ending at the given time
+func sample(signal func(t float64) float64, count int, step float64, end float64) []Point {
+	points := make([]Point, count)
+
+	for i := range points {
+		t := end - float64(count-1-i)*step
+		points[i] = Point{Time: start.Add(time.Duration(t * float64(time.Second))), Value: signal(t)}
+	}
+
+	return points
+}
+
+func settingsFor(t *testing.T, method Method) Settings {
+	settings := Settings{Method: method, Window: 7}
+
+	if err := settings.Validate(); err != nil {
+		t.Fatalf("invalid settings for %s: %v", method, err)
+	}
+
+	return settings
+}
+
+func TestEstimateLinear(t *testing.T) {
+	points := sample(func(t float64) float64 { return 3*t - 2 }, 10, 5, 100)
+
+	for _, method := range []Method{DIFFERENCE, SMOOTHED_DIFFERENCE, REGRESSION, SAVITZKY_GOLAY} {
+		if rate := Estimate(points, settingsFor(t, method)); math.Abs(rate-3) > 1e-9 {
+			t.Errorf("%s: rate of 3t-2 is %v, want 3", method, rate)
+		}
+	}
+}
+
+func TestEstimateQuadratic(t *testing.T) {
+	// f(t) = 0.5t², f'(100) = 100
+	signal := func(t float64) float64 { return 0.5 * t * t }
+	points := sample(signal, 10, 1, 100)
+	exact := 100.0
+
+	tests := []struct {
+		method    Method
+		tolerance float64
+	}{
+		// The backward difference is off by half the second derivative times the step
+		{DIFFERENCE, 0.5 + 1e-9},
+		// The slope of a line fitted over six steps lags by half the window
+		{REGRESSION, 3 + 1e-6},
+		// A quadratic fit is exact for a quadratic
+		{SAVITZKY_GOLAY, 1e-6},
+	}
+
+	for _, test := range tests {
+		rate := Estimate(points, settingsFor(t, test.method))
+
+		if math.Abs(rate-exact) > test.tolerance {
+			t.Errorf("%s: rate of 0.5t² at 100 is %v, want %v within %v", test.method, rate, exact, test.tolerance)
+		}
+	}
+}
+
+func TestEstimateSine(t *testing.T) {
+	// f(t) = sin(t/10), sampled every 0.5s, f'(t) = cos(t/10)/10
+	signal := func(t float64) float64 { return math.Sin(t / 10) }
+	end := 7.0
+	points := sample(signal, 10, 0.5, end)
+	exact := math.Cos(end/10) / 10
+
+	tests := []struct {
+		method    Method
+		tolerance float64
+	}{
+		{DIFFERENCE, 2e-3},
+		{SMOOTHED_DIFFERENCE, 5e-3},
+		{REGRESSION, 1e-2},
+		// The end-point error of a quadratic fit grows with the third derivative
+		{SAVITZKY_GOLAY, 1e-3},
+	}
+
+	for _, test := range tests {
+		rate := Estimate(points, settingsFor(t, test.method))
+
+		if math.Abs(rate-exact) > test.tolerance {
+			t.Errorf("%s: rate of sin(t/10) at %v is %v, want %v within %v", test.method, end, rate, exact, test.tolerance)
+		}
+	}
+}
+
+func TestEstimateSign(t *testing.T) {
+	rising := sample(func(t float64) float64 { return t }, 5, 1, 10)
+	falling := sample(func(t float64) float64 { return -t }, 5, 1, 10)
+
+	// Points are ordered by time whatever order they are given in
+	reversed := make([]Point, len(rising))
+
+	for i, point := range rising {
+		reversed[len(rising)-1-i] = point
+	}
+
+	for _, method := range []Method{DIFFERENCE, SMOOTHED_DIFFERENCE, REGRESSION, SAVITZKY_GOLAY} {
+		settings := settingsFor(t, method)
+
+		if rate := Estimate(rising, settings); rate <= 0 {
+			t.Errorf("%s: rising values have rate %v, want positive", method, rate)
+		}
+
+		if rate := Estimate(reversed, settings); rate <= 0 {
+			t.Errorf("%s: rising values given newest first have rate %v, want positive", method, rate)
+		}
+
+		if rate := Estimate(falling, settings); rate >= 0 {
+			t.Errorf("%s: falling values have rate %v, want negative", method, rate)
+		}
+	}
+}
+
+func TestEstimateTooFewPoints(t *testing.T) {
+	points := []Point{{Time: start, Value: 1}}
+
+	if rate := Estimate(points, DefaultSettings); rate != 0 {
+		t.Errorf("a single point has rate %v, want 0", rate)
+	}
+}
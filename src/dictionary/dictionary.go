@@ -0,0 +1,214 @@
+package dictionary
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"iss-telemetry-analyzer/src/config"
+	"iss-telemetry-analyzer/src/types"
+)
+
+// Entry describes a public telemetry item
+type Entry struct {
+	PUI          string            `json:"pui"`
+	Name         string            `json:"name"` // Friendly name, the PUI when empty
+	Description  string            `json:"description"`
+	Subsystem    string            `json:"subsystem"`
+	Unit         string            `json:"unit"`
+	Enumerations map[string]string `json:"enumerations"` // Raw value to state label
+}
+
+// Dictionary maps PUIs to their metadata
+type Dictionary struct {
+	byPUI map[string]*Entry
+}
+
+// csvColumns are the columns of a CSV dictionary. Enumerations are written
+// as "0=OFF;1=ON".
+var csvColumns = []string{"pui", "name", "description", "subsystem", "unit", "enumerations"}
+
+// LoadFromEnv loads the dictionary at TELEMETRY_DICTIONARY, a local path or
+// an s3://bucket/key location. It returns nil when it is not set.
+func LoadFromEnv() (*Dictionary, error) {
+	location := os.Getenv("TELEMETRY_DICTIONARY")
+
+	if location == "" {
+		return nil, nil
+	}
+
+	return Load(location)
+}
+
+// Load reads a CSV or JSON dictionary, picked by the file extension
+func Load(location string) (*Dictionary, error) {
+	content, err := config.ReadSource(location)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+
+	if strings.ToLower(filepath.Ext(location)) == ".csv" {
+		entries, err = parseCSV(content)
+	} else {
+		err = json.Unmarshal(content, &entries)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse telemetry dictionary %s: %w", location, err)
+	}
+
+	return New(entries)
+}
+
+// New indexes dictionary entries by PUI
+func New(entries []Entry) (*Dictionary, error) {
+	dictionary := &Dictionary{byPUI: map[string]*Entry{}}
+
+	for i := range entries {
+		entry := &entries[i]
+
+		if entry.PUI == "" {
+			return nil, fmt.Errorf("dictionary entry %d has no PUI", i)
+		}
+
+		if _, exists := dictionary.byPUI[entry.PUI]; exists {
+			return nil, fmt.Errorf("PUI %s is listed twice", entry.PUI)
+		}
+
+		if entry.Name == "" {
+			entry.Name = entry.PUI
+		}
+
+		dictionary.byPUI[entry.PUI] = entry
+	}
+
+	return dictionary, nil
+}
+
+// Lookup returns the entry of a PUI
+func (d *Dictionary) Lookup(pui string) (*Entry, bool) {
+	if d == nil {
+		return nil, false
+	}
+
+	entry, ok := d.byPUI[pui]
+
+	return entry, ok
+}
+
+// Enrich renames a reading named by its PUI to its friendly name and adds
+// the dictionary metadata. A unit reported by the producer is kept, since
+// calibration depends on it. Readings of unknown items are left unchanged.
+func (d *Dictionary) Enrich(data *types.TelemetryData) bool {
+	entry, ok := d.Lookup(data.Name)
+
+	if !ok {
+		return false
+	}
+
+	data.PUI = entry.PUI
+	data.Name = entry.Name
+	data.Description = entry.Description
+	data.Subsystem = entry.Subsystem
+
+	if data.Unit == "" {
+		data.Unit = entry.Unit
+	}
+
+	if label, ok := entry.Enumerations[strings.TrimSpace(data.Value)]; ok {
+		data.Label = label
+	}
+
+	return true
+}
+
+func parseCSV(content []byte) ([]Entry, error) {
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+
+	if err != nil {
+		return nil, fmt.Errorf("missing header: %w", err)
+	}
+
+	columns := map[string]int{}
+
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+
+	if _, ok := columns["pui"]; !ok {
+		return nil, fmt.Errorf("missing pui column, expected %s", strings.Join(csvColumns, ","))
+	}
+
+	field := func(record []string, column string) string {
+		i, ok := columns[column]
+
+		if !ok || i >= len(record) {
+			return ""
+		}
+
+		return strings.TrimSpace(record[i])
+	}
+
+	var entries []Entry
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		enumerations, err := parseEnumerations(field(record, "enumerations"))
+
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		entries = append(entries, Entry{
+			PUI:          field(record, "pui"),
+			Name:         field(record, "name"),
+			Description:  field(record, "description"),
+			Subsystem:    field(record, "subsystem"),
+			Unit:         field(record, "unit"),
+			Enumerations: enumerations,
+		})
+	}
+
+	return entries, nil
+}
+
+// parseEnumerations reads "0=OFF;1=ON"
+func parseEnumerations(value string) (map[string]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	enumerations := map[string]string{}
+
+	for _, pair := range strings.Split(value, ";") {
+		raw, label, found := strings.Cut(pair, "=")
+
+		if !found {
+			return nil, fmt.Errorf("invalid enumeration %q", pair)
+		}
+
+		enumerations[strings.TrimSpace(raw)] = strings.TrimSpace(label)
+	}
+
+	return enumerations, nil
+}
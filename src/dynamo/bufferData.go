@@ -1,70 +1,89 @@
 package dynamo
 
 import (
+	"errors"
 	"fmt"
+	"iss-telemetry-analyzer/src/timestamp"
 	"iss-telemetry-analyzer/src/types"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 )
 
+// BucketDuration is the width of the telemetry buckets
+const BucketDuration = 5 * time.Second
+
+// BucketKey returns the key of the bucket containing the given time
+func BucketKey(ts time.Time) string {
+	return ts.UTC().Truncate(BucketDuration).Format(time.RFC3339)
+}
+
+// ErrBucketFinalized is returned by BufferData when the bucket of a reading
+// was finalized, so the reading is late
+var ErrBucketFinalized = errors.New("bucket was already finalized")
+
+// BufferData appends a reading to its bucket unless the bucket was already
+// finalized
 func BufferData(data types.TelemetryData) error {
+	return appendReading(data, true)
+}
+
+// BufferLateData appends a reading to its bucket even when the bucket was
+// already finalized, for the bucket to be rescored
+func BufferLateData(data types.TelemetryData) error {
+	return appendReading(data, false)
+}
+
+// appendReading appends in place, so concurrent appends neither lose
+// readings nor erase the finalized flag
+func appendReading(data types.TelemetryData, onlyOpen bool) error {
 	client := GetDynamoDBClient()
 
-	ts, err := time.Parse(time.RFC3339, data.Timestamp)
+	ts, err := timestamp.Parse(data.Timestamp, timestamp.AUTO, time.Now())
 
 	if err != nil {
 		return fmt.Errorf("invalid timestamp: %v", err)
 	}
 
 	// Floor to nearest 5-second boundary
-	bucketStart := ts.Truncate(5 * time.Second)
-	bucketKey := bucketStart.Format(time.RFC3339)
+	bucketKey := BucketKey(ts)
+
+	reading, err := dynamodbattribute.Marshal(data)
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal data: %v", err)
+	}
 
-	// Retrieve existing bucket data
-	result, err := client.GetItem(&dynamodb.GetItemInput{
+	input := &dynamodb.UpdateItemInput{
 		TableName: aws.String("TelemetryBucket"),
 		Key: map[string]*dynamodb.AttributeValue{
 			"BucketKey": {S: aws.String(bucketKey)},
 		},
-	})
-
-	if err != nil {
-		return fmt.Errorf("failed to retrieve bucket: %v", err)
+		UpdateExpression: aws.String("SET #data = list_append(if_not_exists(#data, :empty), :reading)"),
+		ExpressionAttributeNames: map[string]*string{
+			"#data": aws.String("Data"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":empty":   {L: []*dynamodb.AttributeValue{}},
+			":reading": {L: []*dynamodb.AttributeValue{reading}},
+		},
 	}
 
-	var existingBucket types.DynamoData
-
-	if result.Item != nil {
-		// Unmarshal existing data if the bucket exists
-		if err := dynamodbattribute.UnmarshalMap(result.Item, &existingBucket); err != nil {
-			fmt.Printf("Failed to unmarshal existing bucket data: %v\n", err)
-			return fmt.Errorf("failed to unmarshal existing bucket data: %v", err)
-		}
+	if onlyOpen {
+		input.ConditionExpression = aws.String("attribute_not_exists(Finalized)")
 	}
 
-	// Append new data to the existing slice
-	existingBucket.Data = append(existingBucket.Data, data)
+	_, err = client.UpdateItem(input)
 
-	if existingBucket.BucketKey == nil {
-		existingBucket.BucketKey = aws.String(bucketKey) // Set it to the current bucket key
-	}
-
-	// Marshal updated data to DynamoDB format
-	item, err := dynamodbattribute.MarshalMap(existingBucket)
+	var awsErr awserr.Error
 
-	if err != nil {
-		return fmt.Errorf("failed to marshal data: %v", err)
+	if errors.As(err, &awsErr) && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return ErrBucketFinalized
 	}
 
-	// Save updated bucket to DynamoDB
-	_, err = client.PutItem(&dynamodb.PutItemInput{
-		TableName: aws.String("TelemetryBucket"),
-		Item:      item,
-	})
-
 	if err != nil {
 		return fmt.Errorf("failed to save data to DynamoDB: %v", err)
 	}
@@ -0,0 +1,53 @@
+package dynamo
+
+import (
+	"fmt"
+	"iss-telemetry-analyzer/src/types"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// StoreProcessedData keeps the data produced from a finalized bucket
+func StoreProcessedData(processedData types.ProcessedData) error {
+	return putTimeSeriesItem("ProcessedData", processedData.Timestamp, processedData)
+}
+
+// QueryProcessedData returns processed data between from and to in time order
+func QueryProcessedData(from, to time.Time, after string, limit int) ([]types.ProcessedData, string, error) {
+	items, cursor, err := queryTimeSeries("ProcessedData", from, to, after, limit)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	var processedData []types.ProcessedData
+
+	if err := dynamodbattribute.UnmarshalListOfMaps(items, &processedData); err != nil {
+		return nil, "", fmt.Errorf("failed to unmarshal processed data: %w", err)
+	}
+
+	return processedData, cursor, nil
+}
+
+// StoreAnomalyEvent keeps a bucket whose anomaly level is raised or changed
+func StoreAnomalyEvent(anomalyEvent types.AnomalyEvent) error {
+	return putTimeSeriesItem("AnomalyEvents", anomalyEvent.Timestamp, anomalyEvent)
+}
+
+// QueryAnomalyEvents returns anomaly events between from and to in time order
+func QueryAnomalyEvents(from, to time.Time, after string, limit int) ([]types.AnomalyEvent, string, error) {
+	items, cursor, err := queryTimeSeries("AnomalyEvents", from, to, after, limit)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	var anomalyEvents []types.AnomalyEvent
+
+	if err := dynamodbattribute.UnmarshalListOfMaps(items, &anomalyEvents); err != nil {
+		return nil, "", fmt.Errorf("failed to unmarshal anomaly events: %w", err)
+	}
+
+	return anomalyEvents, cursor, nil
+}
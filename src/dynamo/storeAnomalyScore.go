@@ -12,9 +12,84 @@ import (
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 )
 
-func StoreAnomalyScore(newScore float64) types.StoreAnomalyScoreResult {
+// StoreAnomalyScore adds the score of a bucket to the rolling window and
+// returns the statistics of the other scores. A bucket scored again after
+// late data replaces its earlier score instead of adding a second one.
+func StoreAnomalyScore(bucketKey string, newScore float64) types.StoreAnomalyScoreResult {
 	client := GetDynamoDBClient()
 
+	filteredScores, err := getRecentScores(client)
+
+	if err != nil {
+		return types.StoreAnomalyScoreResult{Error: err}
+	}
+
+	rescored := -1
+
+	// Calculate the average and std of filteredScores
+	var scoresOnly []float64
+	for i, scoreEntry := range filteredScores {
+		if bucket, ok := scoreEntry["bucket"].(string); ok && bucket == bucketKey {
+			rescored = i
+			continue
+		}
+
+		if score, ok := scoreEntry["anomalyScore"].(float64); ok {
+			scoresOnly = append(scoresOnly, score)
+		}
+	}
+
+	avgAnomalyScore := utils.Average(scoresOnly)
+	stdAnomalyScore := utils.StandardDeviation(scoresOnly)
+
+	if rescored >= 0 {
+		// Keep the place of the earlier score in the window
+		filteredScores[rescored]["anomalyScore"] = newScore
+	} else {
+		// Append the new score and timestamp to the front of the filtered array
+		newEntry := map[string]interface{}{
+			"anomalyScore": newScore,
+			"bucket":       bucketKey,
+			"timestamp":    time.Now().UTC().Format(time.RFC3339), // Current timestamp in ISO 8601 format
+		}
+
+		filteredScores = append([]map[string]interface{}{newEntry}, filteredScores...)
+	}
+
+	// Marshal the updated scores array
+	item, err := dynamodbattribute.MarshalMap(map[string]interface{}{
+		"key":    "scores",
+		"scores": filteredScores,
+	})
+
+	if err != nil {
+		return types.StoreAnomalyScoreResult{
+			Error: fmt.Errorf("failed to marshal updated scores: %w", err),
+		}
+	}
+
+	// Update the item in the DynamoDB table
+	_, err = client.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String("AnomalyScores"),
+		Item:      item,
+	})
+
+	if err != nil {
+		return types.StoreAnomalyScoreResult{
+			Error: fmt.Errorf("failed to store updated scores in table: %w", err),
+		}
+	}
+
+	return types.StoreAnomalyScoreResult{
+		Average:           avgAnomalyScore,
+		StandardDeviation: stdAnomalyScore,
+		Error:             nil,
+		Score:             newScore,
+	}
+}
+
+// getRecentScores returns the stored scores of the rolling window
+func getRecentScores(client *dynamodb.DynamoDB) ([]map[string]interface{}, error) {
 	result, err := client.GetItem(&dynamodb.GetItemInput{
 		TableName: aws.String("AnomalyScores"),
 		Key: map[string]*dynamodb.AttributeValue{
@@ -23,9 +98,7 @@ func StoreAnomalyScore(newScore float64) types.StoreAnomalyScoreResult {
 	})
 
 	if err != nil {
-		return types.StoreAnomalyScoreResult{
-			Error: fmt.Errorf("failed to fetch existing scores: %w", err),
-		}
+		return nil, fmt.Errorf("failed to fetch existing scores: %w", err)
 	}
 
 	var existingScores []map[string]interface{}
@@ -36,9 +109,7 @@ func StoreAnomalyScore(newScore float64) types.StoreAnomalyScoreResult {
 			// Unmarshal the "scores" attribute into a []map[string]interface{}
 			if err := dynamodbattribute.Unmarshal(scoresAttr, &existingScores); err != nil {
 				fmt.Printf("Failed to unmarshal existing scores: %v\n", err)
-				return types.StoreAnomalyScoreResult{
-					Error: fmt.Errorf("failed to unmarshal existing scores: %w", err),
-				}
+				return nil, fmt.Errorf("failed to unmarshal existing scores: %w", err)
 			}
 		} else {
 			// Initialize an empty array if the "scores" attribute does not exist
@@ -65,53 +136,31 @@ func StoreAnomalyScore(newScore float64) types.StoreAnomalyScoreResult {
 		}
 	}
 
-	// Calculate the average and std of filteredScores
-	var scoresOnly []float64
-	for _, scoreEntry := range filteredScores {
-		if score, ok := scoreEntry["anomalyScore"].(float64); ok {
-			scoresOnly = append(scoresOnly, score)
-		}
-	}
+	return filteredScores, nil
+}
 
-	avgAnomalyScore := utils.Average(scoresOnly)
-	stdAnomalyScore := utils.StandardDeviation(scoresOnly)
+// GetScoreStats returns the rolling statistics of the recent anomaly scores
+func GetScoreStats() (types.ScoreStats, error) {
+	filteredScores, err := getRecentScores(GetDynamoDBClient())
 
-	// Append the new score and timestamp to the front of the filtered array
-	newEntry := map[string]interface{}{
-		"anomalyScore": newScore,
-		"timestamp":    time.Now().UTC().Format(time.RFC3339), // Current timestamp in ISO 8601 format
+	if err != nil {
+		return types.ScoreStats{}, err
 	}
 
-	filteredScores = append([]map[string]interface{}{newEntry}, filteredScores...)
-
-	// Marshal the updated scores array
-	item, err := dynamodbattribute.MarshalMap(map[string]interface{}{
-		"key":    "scores",
-		"scores": filteredScores,
-	})
-
-	if err != nil {
-		return types.StoreAnomalyScoreResult{
-			Error: fmt.Errorf("failed to marshal updated scores: %w", err),
+	var scoresOnly []float64
+	for _, scoreEntry := range filteredScores {
+		if score, ok := scoreEntry["anomalyScore"].(float64); ok {
+			scoresOnly = append(scoresOnly, score)
 		}
 	}
 
-	// Update the item in the DynamoDB table
-	_, err = client.PutItem(&dynamodb.PutItemInput{
-		TableName: aws.String("AnomalyScores"),
-		Item:      item,
-	})
+	stats := types.ScoreStats{Count: len(scoresOnly)}
 
-	if err != nil {
-		return types.StoreAnomalyScoreResult{
-			Error: fmt.Errorf("failed to store updated scores in table: %w", err),
-		}
+	if len(scoresOnly) > 0 {
+		stats.Average = utils.Average(scoresOnly)
+		stats.StandardDeviation = utils.StandardDeviation(scoresOnly)
+		stats.Latest = scoresOnly[0]
 	}
 
-	return types.StoreAnomalyScoreResult{
-		Average:           avgAnomalyScore,
-		StandardDeviation: stdAnomalyScore,
-		Error:             nil,
-		Score:             newScore,
-	}
+	return stats, nil
 }
@@ -0,0 +1,39 @@
+package dynamo
+
+import (
+	"fmt"
+	"iss-telemetry-analyzer/src/types"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// StoreLateData keeps a reading that arrived after its bucket was finalized
+func StoreLateData(data types.TelemetryData, bucketKey string) error {
+	client := GetDynamoDBClient()
+
+	item, err := dynamodbattribute.MarshalMap(map[string]interface{}{
+		"BucketKey":  bucketKey,
+		"ReceivedAt": time.Now().UTC().Format(time.RFC3339Nano),
+		"Name":       data.Name,
+		"Value":      data.Value,
+		"Timestamp":  data.Timestamp,
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal late data: %v", err)
+	}
+
+	_, err = client.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String("TelemetryLateData"),
+		Item:      item,
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to save late data to DynamoDB: %v", err)
+	}
+
+	return nil
+}
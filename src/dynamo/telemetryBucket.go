@@ -0,0 +1,212 @@
+package dynamo
+
+import (
+	"errors"
+	"fmt"
+	"iss-telemetry-analyzer/src/types"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// GetBucket returns the bucket with the given key, or nil if it has no data
+func GetBucket(bucketKey string) (*types.DynamoData, error) {
+	client := GetDynamoDBClient()
+
+	result, err := client.GetItem(&dynamodb.GetItemInput{
+		TableName:      aws.String("TelemetryBucket"),
+		ConsistentRead: aws.Bool(true),
+		Key: map[string]*dynamodb.AttributeValue{
+			"BucketKey": {S: aws.String(bucketKey)},
+		},
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve bucket %s: %w", bucketKey, err)
+	}
+
+	if result.Item == nil {
+		return nil, nil
+	}
+
+	var bucket types.DynamoData
+
+	if err := dynamodbattribute.UnmarshalMap(result.Item, &bucket); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal bucket %s: %w", bucketKey, err)
+	}
+
+	return &bucket, nil
+}
+
+// MarkBucketFinalized flags a bucket as finalized, which closes it to new
+// readings, and holds it for scoring until the given time. A bucket whose
+// finalizing invocation died before it was scored is taken over once the
+// hold expires. It returns false when another invocation finalized it.
+func MarkBucketFinalized(bucketKey string, scoringUntil time.Time) (bool, error) {
+	client := GetDynamoDBClient()
+
+	_, err := client.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName: aws.String("TelemetryBucket"),
+		Key: map[string]*dynamodb.AttributeValue{
+			"BucketKey": {S: aws.String(bucketKey)},
+		},
+		UpdateExpression: aws.String("SET Finalized = :finalized, ScoringUntil = :scoringUntil"),
+		ConditionExpression: aws.String("attribute_exists(BucketKey) AND (attribute_not_exists(Finalized) OR " +
+			"(attribute_not_exists(Scored) AND ScoringUntil < :now))"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":finalized":    {BOOL: aws.Bool(true)},
+			":scoringUntil": {N: aws.String(strconv.FormatInt(scoringUntil.Unix(), 10))},
+			":now":          {N: aws.String(strconv.FormatInt(time.Now().Unix(), 10))},
+		},
+	})
+
+	var awsErr awserr.Error
+
+	if errors.As(err, &awsErr) && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed to finalize bucket %s: %w", bucketKey, err)
+	}
+
+	return true, nil
+}
+
+// MarkBucketScored records that a finalized bucket was scored and recorded
+func MarkBucketScored(bucketKey string) error {
+	client := GetDynamoDBClient()
+
+	_, err := client.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName: aws.String("TelemetryBucket"),
+		Key: map[string]*dynamodb.AttributeValue{
+			"BucketKey": {S: aws.String(bucketKey)},
+		},
+		UpdateExpression:    aws.String("SET Scored = :scored REMOVE ScoringUntil"),
+		ConditionExpression: aws.String("attribute_exists(BucketKey)"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":scored": {BOOL: aws.Bool(true)},
+		},
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to mark bucket %s scored: %w", bucketKey, err)
+	}
+
+	return nil
+}
+
+// ReopenBucket clears the finalized flag so the bucket is finalized again
+func ReopenBucket(bucketKey string) error {
+	client := GetDynamoDBClient()
+
+	_, err := client.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName: aws.String("TelemetryBucket"),
+		Key: map[string]*dynamodb.AttributeValue{
+			"BucketKey": {S: aws.String(bucketKey)},
+		},
+		UpdateExpression:    aws.String("REMOVE Finalized, Scored, ScoringUntil"),
+		ConditionExpression: aws.String("attribute_exists(BucketKey)"),
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to reopen bucket %s: %w", bucketKey, err)
+	}
+
+	return nil
+}
+
+// GetBuckets returns the existing buckets among the given keys (at most 100)
+func GetBuckets(bucketKeys []string) ([]types.DynamoData, error) {
+	client := GetDynamoDBClient()
+
+	keys := make([]map[string]*dynamodb.AttributeValue, 0, len(bucketKeys))
+
+	for _, bucketKey := range bucketKeys {
+		keys = append(keys, map[string]*dynamodb.AttributeValue{
+			"BucketKey": {S: aws.String(bucketKey)},
+		})
+	}
+
+	requestItems := map[string]*dynamodb.KeysAndAttributes{
+		"TelemetryBucket": {Keys: keys},
+	}
+
+	var buckets []types.DynamoData
+
+	// Retry the keys DynamoDB could not serve in one call
+	for len(requestItems) > 0 {
+		result, err := client.BatchGetItem(&dynamodb.BatchGetItemInput{RequestItems: requestItems})
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve buckets: %w", err)
+		}
+
+		var page []types.DynamoData
+
+		if err := dynamodbattribute.UnmarshalListOfMaps(result.Responses["TelemetryBucket"], &page); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal buckets: %w", err)
+		}
+
+		buckets = append(buckets, page...)
+		requestItems = result.UnprocessedKeys
+	}
+
+	return buckets, nil
+}
+
+// DeleteBucketsBefore deletes the buckets that started before the cutoff
+func DeleteBucketsBefore(cutoff time.Time) (int, error) {
+	client := GetDynamoDBClient()
+
+	var keys []map[string]*dynamodb.AttributeValue
+
+	err := client.ScanPages(&dynamodb.ScanInput{
+		TableName:            aws.String("TelemetryBucket"),
+		ProjectionExpression: aws.String("BucketKey"),
+		FilterExpression:     aws.String("BucketKey < :cutoff"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":cutoff": {S: aws.String(BucketKey(cutoff))},
+		},
+	}, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		keys = append(keys, page.Items...)
+		return true
+	})
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to scan buckets: %w", err)
+	}
+
+	deleted := 0
+
+	// BatchWriteItem accepts up to 25 requests
+	for start := 0; start < len(keys); start += 25 {
+		var requests []*dynamodb.WriteRequest
+
+		for _, key := range keys[start:min(start+25, len(keys))] {
+			requests = append(requests, &dynamodb.WriteRequest{
+				DeleteRequest: &dynamodb.DeleteRequest{Key: key},
+			})
+		}
+
+		requestItems := map[string][]*dynamodb.WriteRequest{"TelemetryBucket": requests}
+
+		for len(requestItems) > 0 {
+			result, err := client.BatchWriteItem(&dynamodb.BatchWriteItemInput{RequestItems: requestItems})
+
+			if err != nil {
+				return deleted, fmt.Errorf("failed to delete buckets: %w", err)
+			}
+
+			requestItems = result.UnprocessedItems
+		}
+
+		deleted += len(requests)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,117 @@
+package dynamo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// Time-series tables are partitioned by UTC day (Day) and sorted by the
+// RFC3339 bucket timestamp (Timestamp)
+
+func dayKey(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+func putTimeSeriesItem(tableName string, timestamp string, value interface{}) error {
+	client := GetDynamoDBClient()
+
+	ts, err := time.Parse(time.RFC3339, timestamp)
+
+	if err != nil {
+		return fmt.Errorf("invalid timestamp: %v", err)
+	}
+
+	item, err := dynamodbattribute.MarshalMap(value)
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s item: %w", tableName, err)
+	}
+
+	item["Day"] = &dynamodb.AttributeValue{S: aws.String(dayKey(ts))}
+	item["Timestamp"] = &dynamodb.AttributeValue{S: aws.String(ts.UTC().Format(time.RFC3339))}
+
+	_, err = client.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String(tableName),
+		Item:      item,
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to save %s item: %w", tableName, err)
+	}
+
+	return nil
+}
+
+// queryTimeSeries returns up to limit items between from and to, starting
+// after the given timestamp when paginating. The returned cursor is the
+// timestamp of the last item, or empty when there are no more items.
+func queryTimeSeries(tableName string, from, to time.Time, after string, limit int) ([]map[string]*dynamodb.AttributeValue, string, error) {
+	client := GetDynamoDBClient()
+
+	start := from
+
+	if after != "" {
+		afterTime, err := time.Parse(time.RFC3339, after)
+
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid cursor: %v", err)
+		}
+
+		start = afterTime
+	}
+
+	var items []map[string]*dynamodb.AttributeValue
+
+	startDay := start.UTC().Truncate(24 * time.Hour)
+
+	for day := startDay; !day.After(to) && len(items) < limit; day = day.Add(24 * time.Hour) {
+		input := &dynamodb.QueryInput{
+			TableName:              aws.String(tableName),
+			KeyConditionExpression: aws.String("#day = :day AND #ts BETWEEN :from AND :to"),
+			ExpressionAttributeNames: map[string]*string{
+				"#day": aws.String("Day"),
+				"#ts":  aws.String("Timestamp"),
+			},
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":day":  {S: aws.String(dayKey(day))},
+				":from": {S: aws.String(from.UTC().Format(time.RFC3339))},
+				":to":   {S: aws.String(to.UTC().Format(time.RFC3339))},
+			},
+		}
+
+		if after != "" && day.Equal(startDay) {
+			input.ExclusiveStartKey = map[string]*dynamodb.AttributeValue{
+				"Day":       {S: aws.String(dayKey(day))},
+				"Timestamp": {S: aws.String(after)},
+			}
+		}
+
+		for len(items) < limit {
+			input.Limit = aws.Int64(int64(limit - len(items)))
+
+			output, err := client.Query(input)
+
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to query %s: %w", tableName, err)
+			}
+
+			items = append(items, output.Items...)
+
+			if output.LastEvaluatedKey == nil {
+				break
+			}
+
+			input.ExclusiveStartKey = output.LastEvaluatedKey
+		}
+	}
+
+	if len(items) < limit {
+		return items, "", nil
+	}
+
+	return items, aws.StringValue(items[len(items)-1]["Timestamp"].S), nil
+}
@@ -0,0 +1,188 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Env supplies the channel values an expression refers to
+type Env interface {
+	Value(channel string) (float64, bool)
+	Previous(channel string) (float64, bool) // Value in the previous bucket
+	Rate(channel string) (float64, bool)     // Change rate in the current bucket
+}
+
+// Expression is a compiled expression. It only reads from its Env, so it
+// cannot reach anything outside the values it is given.
+type Expression struct {
+	source string
+	root   node
+}
+
+func (e *Expression) String() string {
+	return e.source
+}
+
+// References returns the channels the expression reads, sorted
+func (e *Expression) References() []string {
+	var channels []string
+
+	e.root.references(func(channel string) {
+		if !slices.Contains(channels, channel) {
+			channels = append(channels, channel)
+		}
+	})
+
+	slices.Sort(channels)
+
+	return channels
+}
+
+// Evaluate computes the expression. Results that are not finite numbers,
+// such as divisions by zero, are errors.
+func (e *Expression) Evaluate(env Env) (float64, error) {
+	value, err := e.root.evaluate(env)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("%s is not a finite number", e.source)
+	}
+
+	return value, nil
+}
+
+type node interface {
+	evaluate(env Env) (float64, error)
+	references(add func(channel string))
+}
+
+type number struct {
+	value float64
+}
+
+func (n number) evaluate(Env) (float64, error) { return n.value, nil }
+func (n number) references(func(string))       {}
+
+type reference struct {
+	channel string
+}
+
+func (r reference) evaluate(env Env) (float64, error) {
+	value, ok := env.Value(r.channel)
+
+	if !ok {
+		return 0, fmt.Errorf("no value for %s", r.channel)
+	}
+
+	return value, nil
+}
+
+func (r reference) references(add func(string)) { add(r.channel) }
+
+// history reads prev(CHANNEL) or rate(CHANNEL)
+type history struct {
+	function string
+	channel  string
+}
+
+func (h history) evaluate(env Env) (float64, error) {
+	var value float64
+	var ok bool
+
+	if h.function == "prev" {
+		value, ok = env.Previous(h.channel)
+	} else {
+		value, ok = env.Rate(h.channel)
+	}
+
+	if !ok {
+		return 0, fmt.Errorf("no %s value for %s", h.function, h.channel)
+	}
+
+	return value, nil
+}
+
+func (h history) references(add func(string)) { add(h.channel) }
+
+type negate struct {
+	operand node
+}
+
+func (n negate) evaluate(env Env) (float64, error) {
+	value, err := n.operand.evaluate(env)
+
+	return -value, err
+}
+
+func (n negate) references(add func(string)) { n.operand.references(add) }
+
+type binary struct {
+	operator    string
+	left, right node
+}
+
+func (b binary) evaluate(env Env) (float64, error) {
+	left, err := b.left.evaluate(env)
+
+	if err != nil {
+		return 0, err
+	}
+
+	right, err := b.right.evaluate(env)
+
+	if err != nil {
+		return 0, err
+	}
+
+	switch b.operator {
+	case "+":
+		return left + right, nil
+	case "-":
+		return left - right, nil
+	case "*":
+		return left * right, nil
+	case "/":
+		return left / right, nil
+	case "%":
+		return math.Mod(left, right), nil
+	default:
+		return math.Pow(left, right), nil
+	}
+}
+
+func (b binary) references(add func(string)) {
+	b.left.references(add)
+	b.right.references(add)
+}
+
+type call struct {
+	name      string
+	fn        func(arguments []float64) float64
+	arguments []node
+}
+
+func (c call) evaluate(env Env) (float64, error) {
+	arguments := make([]float64, len(c.arguments))
+
+	for i, argument := range c.arguments {
+		value, err := argument.evaluate(env)
+
+		if err != nil {
+			return 0, err
+		}
+
+		arguments[i] = value
+	}
+
+	return c.fn(arguments), nil
+}
+
+func (c call) references(add func(string)) {
+	for _, argument := range c.arguments {
+		argument.references(add)
+	}
+}
@@ -0,0 +1,54 @@
+package expr
+
+import "math"
+
+type function struct {
+	minArgs int
+	maxArgs int // -1 for any number
+	apply   func(arguments []float64) float64
+}
+
+func unary(fn func(float64) float64) function {
+	return function{minArgs: 1, maxArgs: 1, apply: func(a []float64) float64 { return fn(a[0]) }}
+}
+
+// functions are the math functions expressions may call
+var functions = map[string]function{
+	"abs":   unary(math.Abs),
+	"sqrt":  unary(math.Sqrt),
+	"exp":   unary(math.Exp),
+	"ln":    unary(math.Log),
+	"log":   unary(math.Log),
+	"log10": unary(math.Log10),
+	"sin":   unary(math.Sin),
+	"cos":   unary(math.Cos),
+	"tan":   unary(math.Tan),
+	"asin":  unary(math.Asin),
+	"acos":  unary(math.Acos),
+	"atan":  unary(math.Atan),
+	"floor": unary(math.Floor),
+	"ceil":  unary(math.Ceil),
+	"round": unary(math.Round),
+	"pow":   {minArgs: 2, maxArgs: 2, apply: func(a []float64) float64 { return math.Pow(a[0], a[1]) }},
+	"atan2": {minArgs: 2, maxArgs: 2, apply: func(a []float64) float64 { return math.Atan2(a[0], a[1]) }},
+	"hypot": {minArgs: 2, maxArgs: 2, apply: func(a []float64) float64 { return math.Hypot(a[0], a[1]) }},
+	"clamp": {minArgs: 3, maxArgs: 3, apply: func(a []float64) float64 { return math.Min(math.Max(a[0], a[1]), a[2]) }},
+	"min": {minArgs: 1, maxArgs: -1, apply: func(a []float64) float64 {
+		result := a[0]
+
+		for _, value := range a[1:] {
+			result = math.Min(result, value)
+		}
+
+		return result
+	}},
+	"max": {minArgs: 1, maxArgs: -1, apply: func(a []float64) float64 {
+		result := a[0]
+
+		for _, value := range a[1:] {
+			result = math.Max(result, value)
+		}
+
+		return result
+	}},
+}
@@ -0,0 +1,260 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+)
+
+// Limits that keep expressions from the configuration cheap to evaluate
+const (
+	maxSourceLength = 1024
+	maxDepth        = 32
+)
+
+type parser struct {
+	tokens []token
+	pos    int
+	depth  int
+}
+
+// Parse compiles an expression. Expressions combine numbers, channel
+// references and the constants pi and e with + - * / % ^ and the functions
+// of the functions table, plus prev(CHANNEL), the value of a channel in the
+// previous bucket, and rate(CHANNEL), its change rate.
+func Parse(source string) (*Expression, error) {
+	if len(source) > maxSourceLength {
+		return nil, fmt.Errorf("expression is longer than %d characters", maxSourceLength)
+	}
+
+	tokens, err := tokenize(source)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseSum()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if next := p.peek(); next.kind != tokenEnd {
+		return nil, fmt.Errorf("unexpected %q at position %d", next.text, next.pos)
+	}
+
+	return &Expression{source: source, root: root}, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+
+	if t.kind != tokenEnd {
+		p.pos++
+	}
+
+	return t
+}
+
+func (p *parser) enter() error {
+	p.depth++
+
+	if p.depth > maxDepth {
+		return fmt.Errorf("expression is nested more than %d levels deep", maxDepth)
+	}
+
+	return nil
+}
+
+// sum := product (("+" | "-") product)*
+func (p *parser) parseSum() (node, error) {
+	left, err := p.parseProduct()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokenOperator && (p.peek().text == "+" || p.peek().text == "-") {
+		operator := p.next().text
+		right, err := p.parseProduct()
+
+		if err != nil {
+			return nil, err
+		}
+
+		left = binary{operator: operator, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+// product := unary (("*" | "/" | "%") unary)*
+func (p *parser) parseProduct() (node, error) {
+	left, err := p.parseUnary()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokenOperator && (p.peek().text == "*" || p.peek().text == "/" || p.peek().text == "%") {
+		operator := p.next().text
+		right, err := p.parseUnary()
+
+		if err != nil {
+			return nil, err
+		}
+
+		left = binary{operator: operator, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+// unary := ("-" | "+") unary | power
+func (p *parser) parseUnary() (node, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+
+	if t := p.peek(); t.kind == tokenOperator && (t.text == "-" || t.text == "+") {
+		p.next()
+		operand, err := p.parseUnary()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if t.text == "+" {
+			return operand, nil
+		}
+
+		return negate{operand: operand}, nil
+	}
+
+	return p.parsePower()
+}
+
+// power := primary ("^" unary)?, right associative
+func (p *parser) parsePower() (node, error) {
+	base, err := p.parsePrimary()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind == tokenOperator && t.text == "^" {
+		p.next()
+		exponent, err := p.parseUnary()
+
+		if err != nil {
+			return nil, err
+		}
+
+		return binary{operator: "^", left: base, right: exponent}, nil
+	}
+
+	return base, nil
+}
+
+// primary := number | name | name "(" arguments ")" | "(" sum ")"
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokenNumber:
+		return number{value: t.number}, nil
+
+	case tokenLeftParen:
+		inner, err := p.parseSum()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if closing := p.next(); closing.kind != tokenRightParen {
+			return nil, fmt.Errorf("expected ) at position %d", closing.pos)
+		}
+
+		return inner, nil
+
+	case tokenIdent:
+		if p.peek().kind == tokenLeftParen {
+			return p.parseCall(t)
+		}
+
+		switch t.text {
+		case "pi":
+			return number{value: math.Pi}, nil
+		case "e":
+			return number{value: math.E}, nil
+		}
+
+		return reference{channel: t.text}, nil
+
+	case tokenEnd:
+		return nil, fmt.Errorf("unexpected end of expression")
+
+	default:
+		return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.pos)
+	}
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	p.next() // (
+
+	var arguments []node
+
+	if p.peek().kind != tokenRightParen {
+		for {
+			argument, err := p.parseSum()
+
+			if err != nil {
+				return nil, err
+			}
+
+			arguments = append(arguments, argument)
+
+			if p.peek().kind != tokenComma {
+				break
+			}
+
+			p.next()
+		}
+	}
+
+	if closing := p.next(); closing.kind != tokenRightParen {
+		return nil, fmt.Errorf("expected ) at position %d", closing.pos)
+	}
+
+	switch name.text {
+	case "prev", "rate":
+		if len(arguments) != 1 {
+			return nil, fmt.Errorf("%s takes one channel", name.text)
+		}
+
+		ref, ok := arguments[0].(reference)
+
+		if !ok {
+			return nil, fmt.Errorf("%s takes a channel name at position %d", name.text, name.pos)
+		}
+
+		return history{function: name.text, channel: ref.channel}, nil
+	}
+
+	fn, ok := functions[name.text]
+
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s at position %d", name.text, name.pos)
+	}
+
+	if len(arguments) < fn.minArgs || (fn.maxArgs >= 0 && len(arguments) > fn.maxArgs) {
+		return nil, fmt.Errorf("wrong number of arguments for %s at position %d", name.text, name.pos)
+	}
+
+	return call{name: name.text, fn: fn.apply, arguments: arguments}, nil
+}
@@ -0,0 +1,172 @@
+package features
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"iss-telemetry-analyzer/src/channels"
+	"iss-telemetry-analyzer/src/config"
+	"iss-telemetry-analyzer/src/sagemaker"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// Schema declares the names of the model features in vector order
+type Schema struct {
+	Features []string `json:"features"`
+}
+
+// Values holds computed feature values by name
+type Values map[string]float64
+
+// Name builds the feature name of a channel role, e.g. FLOWRATE.change_rate
+func Name(channel string, role channels.Role) string {
+	return channel + "." + string(role)
+}
+
+// StateName builds the one-hot feature name of a state, e.g. PUMP.state.ON
+func StateName(channel string, state string) string {
+	return channel + ".state." + state
+}
+
+// WindowName builds the feature name of a window statistic, e.g.
+// PRESSURE.mean_5m
+func WindowName(channel string, key string) string {
+	return channel + "." + key
+}
+
+// SpectrumName builds the feature name of a spectral feature, e.g.
+// PUMP_SPEED.dominant_frequency
+func SpectrumName(channel string, key string) string {
+	return channel + "." + key
+}
+
+// CouplingName builds the feature name of a coupling role, e.g.
+// FLOWRATE~PRESSURE.pearson
+func CouplingName(coupling string, role channels.Role) string {
+	return coupling + "." + string(role)
+}
+
+// SlotName builds the feature name of a feature vector slot
+func SlotName(slot channels.FeatureSlot) string {
+	if slot.Coupling != "" {
+		return CouplingName(slot.Coupling, slot.Role)
+	}
+
+	switch slot.Role {
+	case channels.ONE_HOT:
+		return StateName(slot.Channel, slot.State)
+	case channels.WINDOW:
+		return WindowName(slot.Channel, slot.Window)
+	case channels.SPECTRUM:
+		return SpectrumName(slot.Channel, slot.Feature)
+	}
+
+	return Name(slot.Channel, slot.Role)
+}
+
+// LoadSchema loads the feature schema shipped next to the scaler parameters.
+// When no schema was shipped it is derived from the channel registry, with a
+// warning since that layout may differ from the one the model was trained
+// with. Validate still catches a layout of the wrong length.
+func LoadSchema(registry *channels.Registry) (*Schema, error) {
+	bucketName := os.Getenv("S3_BUCKET_NAME")
+	if bucketName == "" {
+		return nil, fmt.Errorf("S3_BUCKET_NAME environment variable is not set")
+	}
+
+	location := "s3://" + bucketName + "/models/feature_schema.json"
+	content, err := config.ReadSource(location)
+
+	var awsErr awserr.Error
+
+	if errors.As(err, &awsErr) && awsErr.Code() == s3.ErrCodeNoSuchKey {
+		fmt.Printf("Warning: feature schema %s is missing, deriving it from the channel registry\n", location)
+		return SchemaFromRegistry(registry), nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load feature schema: %w", err)
+	}
+
+	var schema Schema
+
+	if err := json.Unmarshal(content, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse feature schema: %w", err)
+	}
+
+	return &schema, nil
+}
+
+// SchemaFromRegistry lays out the feature roles declared in the registry
+func SchemaFromRegistry(registry *channels.Registry) *Schema {
+	var schema Schema
+
+	for _, slot := range registry.FeatureSlots() {
+		schema.Features = append(schema.Features, SlotName(slot))
+	}
+
+	return &schema
+}
+
+// Validate checks the schema against the features the scaler was fitted on
+func (s *Schema) Validate(params *sagemaker.RobustScalerParams) error {
+	seen := map[string]bool{}
+
+	for i, name := range s.Features {
+		if seen[name] {
+			return fmt.Errorf("feature schema declares %s twice (position %d)", name, i)
+		}
+
+		seen[name] = true
+	}
+
+	if len(s.Features) != len(params.Medians) || len(s.Features) != len(params.IQRs) {
+		return fmt.Errorf("feature schema has %d features but scaler has %d centers and %d scales",
+			len(s.Features), len(params.Medians), len(params.IQRs))
+	}
+
+	if len(params.FeatureNames) == 0 {
+		return nil
+	}
+
+	if len(params.FeatureNames) != len(s.Features) {
+		return fmt.Errorf("feature schema has %d features but scaler was fitted on %d", len(s.Features), len(params.FeatureNames))
+	}
+
+	for i, name := range s.Features {
+		if params.FeatureNames[i] != name {
+			return fmt.Errorf("feature %d is %s in the schema but %s in the scaler (schema order: %s)",
+				i, name, params.FeatureNames[i], strings.Join(s.Features, ", "))
+		}
+	}
+
+	return nil
+}
+
+// Build assembles the feature vector in schema order
+func (s *Schema) Build(values Values) ([]float64, error) {
+	vector := make([]float64, len(s.Features))
+	var missing []string
+
+	for i, name := range s.Features {
+		value, ok := values[name]
+
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+
+		vector[i] = value
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing values for features: %s", strings.Join(missing, ", "))
+	}
+
+	return vector, nil
+}
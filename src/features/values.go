@@ -0,0 +1,52 @@
+package features
+
+import (
+	"iss-telemetry-analyzer/src/channels"
+	"iss-telemetry-analyzer/src/types"
+)
+
+// FromProcessedData exposes the value, change rate, window statistics and
+// spectral features of numeric channels, the state index and one-hot states of discrete channels
+// and the correlations of couplings
+func FromProcessedData(registry *channels.Registry, processedData types.ProcessedData) Values {
+	values := Values{}
+
+	for name, channelValue := range processedData.Channels {
+		channel, ok := registry.Get(name)
+
+		if !ok || !channel.IsDiscrete() {
+			values[Name(name, channels.VALUE)] = channelValue.Value
+			values[Name(name, channels.CHANGE_RATE)] = channelValue.ChangeRate
+
+			for key, value := range channelValue.Windows {
+				values[WindowName(name, key)] = value
+			}
+
+			for key, value := range channelValue.Spectrum {
+				values[SpectrumName(name, key)] = value
+			}
+
+			continue
+		}
+
+		values[Name(name, channels.STATE_INDEX)] = channelValue.Value
+
+		for _, state := range channel.StateNames() {
+			oneHot := 0.0
+
+			if state == channelValue.State {
+				oneHot = 1
+			}
+
+			values[StateName(name, state)] = oneHot
+		}
+	}
+
+	for name, couplingValue := range processedData.Couplings {
+		values[CouplingName(name, channels.PEARSON)] = couplingValue.Pearson
+		values[CouplingName(name, channels.CROSS_CORRELATION)] = couplingValue.CrossCorrelation
+		values[CouplingName(name, channels.LAG)] = couplingValue.Lag
+	}
+
+	return values
+}
@@ -0,0 +1,158 @@
+package ingest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"iss-telemetry-analyzer/src/ccsds"
+	"iss-telemetry-analyzer/src/types"
+)
+
+// Format is the encoding of the readings inside a record
+type Format string
+
+const (
+	JSON  Format = "json"  // ISS Live telemetry JSON objects
+	CCSDS Format = "ccsds" // CCSDS space packets
+)
+
+// maxDecompressedSize guards against gzip bombs
+const maxDecompressedSize = 64 * 1024 * 1024
+
+// maxNesting bounds how deep compressed and aggregated payloads may nest
+const maxNesting = 4
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// Decoder unpacks record payloads into telemetry readings
+type Decoder struct {
+	format  Format
+	packets *ccsds.Decoder
+}
+
+// NewDecoderFromEnv selects the input format from TELEMETRY_FORMAT, "json"
+// by default or "ccsds"
+func NewDecoderFromEnv() (*Decoder, error) {
+	format := Format(os.Getenv("TELEMETRY_FORMAT"))
+
+	switch format {
+	case "", JSON:
+		return &Decoder{format: JSON}, nil
+	case CCSDS:
+		packets, err := ccsds.NewDecoderFromEnv()
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &Decoder{format: CCSDS, packets: packets}, nil
+	default:
+		return nil, fmt.Errorf("invalid TELEMETRY_FORMAT %q", format)
+	}
+}
+
+// Decode unpacks a record payload into telemetry readings. Payloads may be
+// gzip-compressed or KPL-aggregated. JSON payloads hold a single object, an
+// array or newline-delimited objects; CCSDS payloads concatenated packets.
+func (d *Decoder) Decode(payload []byte) ([]types.TelemetryData, error) {
+	return d.decode(payload, 0)
+}
+
+func (d *Decoder) decode(payload []byte, depth int) ([]types.TelemetryData, error) {
+	if depth > maxNesting {
+		return nil, errors.New("payload is nested too deeply")
+	}
+
+	if bytes.HasPrefix(payload, gzipMagic) {
+		decompressed, err := gunzip(payload)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return d.decode(decompressed, depth+1)
+	}
+
+	if records, ok := deaggregate(payload); ok {
+		var readings []types.TelemetryData
+
+		for i, record := range records {
+			recordReadings, err := d.decode(record, depth+1)
+
+			if err != nil {
+				return nil, fmt.Errorf("aggregated record %d: %w", i, err)
+			}
+
+			readings = append(readings, recordReadings...)
+		}
+
+		return readings, nil
+	}
+
+	if d.format == CCSDS {
+		return d.packets.Decode(payload)
+	}
+
+	return decodeJSON(payload)
+}
+
+func gunzip(payload []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(payload))
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid gzip payload: %w", err)
+	}
+	defer reader.Close()
+
+	decompressed, err := io.ReadAll(io.LimitReader(reader, maxDecompressedSize+1))
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid gzip payload: %w", err)
+	}
+
+	if len(decompressed) > maxDecompressedSize {
+		return nil, fmt.Errorf("decompressed payload exceeds %d bytes", maxDecompressedSize)
+	}
+
+	return decompressed, nil
+}
+
+// decodeJSON reads a JSON array of readings, or a sequence of reading
+// objects separated by whitespace, which covers single objects and NDJSON
+func decodeJSON(payload []byte) ([]types.TelemetryData, error) {
+	trimmed := bytes.TrimSpace(payload)
+
+	if len(trimmed) == 0 {
+		return nil, errors.New("empty payload")
+	}
+
+	if trimmed[0] == '[' {
+		var readings []types.TelemetryData
+
+		if err := json.Unmarshal(trimmed, &readings); err != nil {
+			return nil, fmt.Errorf("invalid JSON array: %w", err)
+		}
+
+		return readings, nil
+	}
+
+	var readings []types.TelemetryData
+	decoder := json.NewDecoder(bytes.NewReader(trimmed))
+
+	for line := 1; decoder.More(); line++ {
+		var reading types.TelemetryData
+
+		if err := decoder.Decode(&reading); err != nil {
+			return nil, fmt.Errorf("invalid JSON value %d: %w", line, err)
+		}
+
+		readings = append(readings, reading)
+	}
+
+	return readings, nil
+}
@@ -0,0 +1,146 @@
+package ingest
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// KPL aggregated records are the magic header, a protobuf AggregatedRecord
+// and the MD5 of the protobuf:
+//
+//	message AggregatedRecord {
+//	  repeated string partition_key_table     = 1;
+//	  repeated string explicit_hash_key_table = 2;
+//	  repeated Record records                 = 3;
+//	}
+//
+//	message Record {
+//	  required uint64 partition_key_index     = 1;
+//	  optional uint64 explicit_hash_key_index = 2;
+//	  required bytes  data                    = 3;
+//	  repeated Tag    tags                    = 4;
+//	}
+var kplMagic = []byte{0xf3, 0x89, 0x9a, 0xc2}
+
+// Protobuf wire types
+const (
+	wireVarint          = 0
+	wireFixed64         = 1
+	wireLengthDelimited = 2
+	wireFixed32         = 5
+)
+
+const (
+	aggregatedRecordsField = 3
+	recordDataField        = 3
+)
+
+// deaggregate returns the user records of a KPL aggregated record. Like the
+// KPL itself, payloads with a bad checksum are treated as plain records.
+func deaggregate(payload []byte) ([][]byte, bool) {
+	if len(payload) < len(kplMagic)+md5.Size || !bytes.HasPrefix(payload, kplMagic) {
+		return nil, false
+	}
+
+	message := payload[len(kplMagic) : len(payload)-md5.Size]
+	checksum := md5.Sum(message)
+
+	if !bytes.Equal(checksum[:], payload[len(payload)-md5.Size:]) {
+		return nil, false
+	}
+
+	var records [][]byte
+
+	err := readFields(message, func(field int, value []byte) error {
+		if field != aggregatedRecordsField {
+			return nil
+		}
+
+		var data []byte
+
+		err := readFields(value, func(recordField int, recordValue []byte) error {
+			if recordField == recordDataField {
+				data = recordValue
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return err
+		}
+
+		records = append(records, data)
+
+		return nil
+	})
+
+	if err != nil {
+		fmt.Printf("Invalid KPL aggregated record: %v\n", err)
+		return nil, false
+	}
+
+	return records, true
+}
+
+// readFields walks a protobuf message and calls fn with the raw bytes of
+// every length-delimited field. Other wire types are skipped.
+func readFields(message []byte, fn func(field int, value []byte) error) error {
+	for len(message) > 0 {
+		key, n := binary.Uvarint(message)
+
+		if n <= 0 {
+			return errors.New("invalid field key")
+		}
+
+		message = message[n:]
+		field := int(key >> 3)
+
+		switch key & 0x7 {
+		case wireVarint:
+			_, n := binary.Uvarint(message)
+
+			if n <= 0 {
+				return fmt.Errorf("invalid varint in field %d", field)
+			}
+
+			message = message[n:]
+
+		case wireFixed64:
+			if len(message) < 8 {
+				return fmt.Errorf("truncated field %d", field)
+			}
+
+			message = message[8:]
+
+		case wireFixed32:
+			if len(message) < 4 {
+				return fmt.Errorf("truncated field %d", field)
+			}
+
+			message = message[4:]
+
+		case wireLengthDelimited:
+			length, n := binary.Uvarint(message)
+
+			if n <= 0 || length > uint64(len(message)-n) {
+				return fmt.Errorf("truncated field %d", field)
+			}
+
+			value := message[n : n+int(length)]
+			message = message[n+int(length):]
+
+			if err := fn(field, value); err != nil {
+				return err
+			}
+
+		default:
+			return fmt.Errorf("unsupported wire type in field %d", field)
+		}
+	}
+
+	return nil
+}
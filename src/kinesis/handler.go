@@ -2,152 +2,57 @@ package kinesis
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"iss-telemetry-analyzer/src/dynamo"
-	"iss-telemetry-analyzer/src/sagemaker"
-	"iss-telemetry-analyzer/src/types"
-	"iss-telemetry-analyzer/src/utils"
-	"os"
-	"strconv"
+	"iss-telemetry-analyzer/src/pipeline"
 
 	"github.com/aws/aws-lambda-go/events"
 )
 
-var currentTemperatureValue float64
-var currentTemperatureTimestamp string
-var previousTemperatureValue float64
-var previousTemperatureTimestamp string
+// Handler processes the records of the batch in order and stops at the first
+// record that fails. That record and the ones after it are reported as
+// failures, since Lambda retries the shard from the failed sequence number
+// anyway; records after it must not move the watermark past readings that
+// are still to come. Buckets that ended before the watermark are then
+// finalized.
+func Handler(ctx context.Context, kinesisEvent events.KinesisEvent) (events.KinesisEventResponse, error) {
+	var response events.KinesisEventResponse
 
-// Pressure
-var currentPressureValue float64
-var currentPressureTimestamp string
-var previousPressureValue float64
-var previousPressureTimestamp string
+	batch, err := pipeline.NewBatch(ctx, streamName(kinesisEvent))
 
-// Flowrate
-var currentFlowRateValue float64
-var currentFlowRateTimestamp string
-var previousFlowrateValue float64
-var previousFlowrateTimestamp string
-
-func Handler(ctx context.Context, kinesisEvent events.KinesisEvent) error {
-
-	// Process each Kinesis record
-	record := kinesisEvent.Records[0]
-	dataBytes := record.Kinesis.Data
-
-	var telemetryData types.TelemetryData
-
-	if err := json.Unmarshal(dataBytes, &telemetryData); err != nil {
-		fmt.Printf("Cannot read Kinesis telemetry data: %v\n", err)
+	if err != nil {
+		return response, err
 	}
 
-	if telemetryData.Name == "FLOWRATE" {
-		flowrate, err := strconv.ParseFloat(telemetryData.Value, 64)
+	failed := false
 
-		if err != nil {
-			fmt.Printf("Error parsing %s value %s: %v\n", telemetryData.Name, telemetryData.Value, err)
-		}
+	for _, record := range kinesisEvent.Records {
+		if !failed {
+			// Event IDs are "<shard ID>:<sequence number>"
+			err := batch.Ingest(ctx, record.EventID, record.Kinesis.Data)
 
-		currentFlowRateValue = flowrate
-		currentFlowRateTimestamp = telemetryData.Timestamp
-	}
-
-	if telemetryData.Name == "TEMPERATURE" {
-		temperature, err := strconv.ParseFloat(telemetryData.Value, 64)
+			if err == nil {
+				continue
+			}
 
-		if err != nil {
-			fmt.Printf("Error parsing %s value %s: %v\n", telemetryData.Name, telemetryData.Value, err)
+			fmt.Printf("Error processing record %s, retrying from it: %v\n", record.Kinesis.SequenceNumber, err)
+			failed = true
 		}
 
-		currentTemperatureValue = temperature
-		currentTemperatureTimestamp = telemetryData.Timestamp
+		response.BatchItemFailures = append(response.BatchItemFailures, events.KinesisBatchItemFailure{
+			ItemIdentifier: record.Kinesis.SequenceNumber,
+		})
 	}
 
-	if telemetryData.Name == "PRESSURE" {
-		pressure, err := strconv.ParseFloat(telemetryData.Value, 64)
-
-		if err != nil {
-			fmt.Printf("Error parsing %s value %s: %v\n", telemetryData.Name, telemetryData.Value, err)
-		}
-
-		currentPressureValue = pressure
-		currentPressureTimestamp = telemetryData.Timestamp
-	}
-
-	if currentFlowRateValue != 0.0 && currentTemperatureValue != 0.0 && currentPressureValue != 0.0 {
-
-		flowChangeRate := utils.GetChangeRate(currentFlowRateValue, previousFlowrateValue, currentFlowRateTimestamp, previousFlowrateTimestamp)
-		temperatureChangeRate := utils.GetChangeRate(currentTemperatureValue, previousTemperatureValue, currentTemperatureTimestamp, previousTemperatureTimestamp)
-		pressureChangeRate := utils.GetChangeRate(currentPressureValue, previousPressureValue, currentPressureTimestamp, previousPressureTimestamp)
-
-		features := []float64{
-			currentFlowRateValue, currentPressureValue, currentPressureValue,
-			flowChangeRate, pressureChangeRate, temperatureChangeRate,
-		}
-
-		scalerParams, err := sagemaker.LoadRobustScalerParams()
-
-		if err != nil {
-			fmt.Println("Error scaling features: ", err)
+	batch.Finish(ctx)
 
-			os.Exit(1)
-		}
-
-		scaledFeatures := sagemaker.RobustScale(features, scalerParams)
-		fmt.Println("Scaled features!: ", scaledFeatures)
-
-		var anomalyScore = sagemaker.Predict(scaledFeatures)
-
-		scoreResult := dynamo.StoreAnomalyScore(anomalyScore)
-
-		if scoreResult.Error != nil {
-			fmt.Println("STORE ERRORS: ", scoreResult.Error)
-		}
-
-		// Log the telemetry data for querying in Grafana (structured JSON format)
-		// Add random deviation to upper and lower anomaly score deviation limits
-
-		upperLimit := scoreResult.Average + 3*scoreResult.StandardDeviation + 0
-		lowerLimit := scoreResult.Average - 3*scoreResult.StandardDeviation - 0
-
-		logData := map[string]interface{}{
-			"timestamp":                           currentFlowRateTimestamp,
-			"flowrate":                            currentFlowRateValue,
-			"pressure":                            currentPressureValue,
-			"temperature":                         currentTemperatureValue,
-			"flow_change_rate":                    flowChangeRate,
-			"press_change_rate":                   pressureChangeRate,
-			"temp_change_rate":                    temperatureChangeRate,
-			"anomaly_score":                       anomalyScore,
-			"log_type":                            "telemetry_data",
-			"moving_avg_score":                    scoreResult.Average,
-			"moving_avg_std":                      scoreResult.StandardDeviation,
-			"upper_anomaly_score_deviation_limit": upperLimit,
-			"lower_anomaly_score_deviation_limit": lowerLimit,
-		}
-
-		logDataBytes, err := json.Marshal(logData)
-
-		if err != nil {
-			fmt.Printf("Error marshaling log data: %v\n", err)
-		} else {
-			// Print log data as JSON for CloudWatch and Grafana to query
-			fmt.Println(string(logDataBytes))
-		}
-
-		// Update values
-		previousFlowrateTimestamp = currentFlowRateTimestamp
-		previousFlowrateValue = currentFlowRateValue
-
-		previousPressureTimestamp = currentPressureTimestamp
-		previousPressureValue = currentPressureValue
-
-		previousTemperatureTimestamp = currentTemperatureTimestamp
-		previousTemperatureValue = currentTemperatureValue
+	return response, nil
+}
 
+// streamName identifies the stream the batch was read from
+func streamName(kinesisEvent events.KinesisEvent) string {
+	if len(kinesisEvent.Records) == 0 || kinesisEvent.Records[0].EventSourceArn == "" {
+		return "default"
 	}
 
-	return nil
+	return kinesisEvent.Records[0].EventSourceArn
 }
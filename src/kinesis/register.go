@@ -0,0 +1,17 @@
+package kinesis
+
+import (
+	"encoding/json"
+	"iss-telemetry-analyzer/src/router"
+)
+
+func init() {
+	router.Register(router.Source{
+		Name:     "kinesis",
+		Priority: 10,
+		Detect: func(event json.RawMessage) bool {
+			return router.RecordsFrom(event, "aws:kinesis")
+		},
+		Handle: router.Typed(Handler),
+	})
+}
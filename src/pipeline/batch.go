@@ -0,0 +1,533 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iss-telemetry-analyzer/src/buckets"
+	"iss-telemetry-analyzer/src/ccsds"
+	"iss-telemetry-analyzer/src/channels"
+	"iss-telemetry-analyzer/src/dedup"
+	"iss-telemetry-analyzer/src/dictionary"
+	"iss-telemetry-analyzer/src/dynamo"
+	"iss-telemetry-analyzer/src/ingest"
+	"iss-telemetry-analyzer/src/quality"
+	"iss-telemetry-analyzer/src/state"
+	"iss-telemetry-analyzer/src/timestamp"
+	"iss-telemetry-analyzer/src/types"
+	"strconv"
+	"time"
+)
+
+// Batch ingests the records of one invocation and then finalizes the
+// buckets they complete
+type Batch struct {
+	stream          string
+	registry        *channels.Registry
+	decoder         *ingest.Decoder
+	dictionary      *dictionary.Dictionary
+	deduplicator    *dedup.Deduplicator
+	quarantine      quality.Quarantine
+	latePolicy      buckets.LatePolicy
+	allowedLateness time.Duration
+	idleTimeout     time.Duration
+	lastFinalized   time.Time
+	latestEventTime time.Time
+	rescoreBuckets  map[string]bool
+	rejections      map[quality.Check]int
+	stats           batchStats
+}
+
+// batchStats is logged at the end of every batch
+type batchStats struct {
+	Records     int `json:"records"`
+	Readings    int `json:"readings"`
+	Malformed   int `json:"malformed"`
+	Failed      int `json:"failed"`
+	Duplicates  int `json:"duplicates"`
+	Late        int `json:"late"`
+	Dropped     int `json:"dropped"`
+	Diverted    int `json:"diverted"`
+	Quarantined int `json:"quarantined"`
+	Rescored    int `json:"rescored_buckets"`
+}
+
+// NewBatch prepares a batch of records read from the given stream
+func NewBatch(ctx context.Context, stream string) (*Batch, error) {
+	registry, err := getRegistry()
+
+	if err != nil {
+		return nil, fmt.Errorf("error loading channel registry: %w", err)
+	}
+
+	latePolicy, err := buckets.LatePolicyFromEnv()
+
+	if err != nil {
+		return nil, err
+	}
+
+	allowedLateness, err := buckets.AllowedLatenessFromEnv()
+
+	if err != nil {
+		return nil, err
+	}
+
+	idleTimeout, err := buckets.StreamIdleTimeoutFromEnv()
+
+	if err != nil {
+		return nil, err
+	}
+
+	decoder, err := getDecoder()
+
+	if err != nil {
+		return nil, err
+	}
+
+	telemetryDictionary, err := getDictionary()
+
+	if err != nil {
+		return nil, fmt.Errorf("error loading telemetry dictionary: %w", err)
+	}
+
+	deduplicator, err := getDeduplicator()
+
+	if err != nil {
+		return nil, err
+	}
+
+	quarantine, err := getQuarantine()
+
+	if err != nil {
+		return nil, err
+	}
+
+	lastFinalized, err := buckets.LastFinalized(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Batch{
+		stream:          stream,
+		registry:        registry,
+		decoder:         decoder,
+		dictionary:      telemetryDictionary,
+		deduplicator:    deduplicator,
+		quarantine:      quarantine,
+		latePolicy:      latePolicy,
+		allowedLateness: allowedLateness,
+		idleTimeout:     idleTimeout,
+		lastFinalized:   lastFinalized,
+		rescoreBuckets:  map[string]bool{},
+		rejections:      map[quality.Check]int{},
+	}, nil
+}
+
+// Ingest processes the payload of one source record. Records failing with an
+// error can be retried without side effects.
+func (b *Batch) Ingest(ctx context.Context, recordID string, payload []byte) error {
+	b.stats.Records++
+
+	if err := b.processRecord(ctx, recordID, payload); err != nil {
+		b.stats.Failed++
+		return err
+	}
+
+	return nil
+}
+
+// Finish rescores buckets that received late data, advances the stream
+// watermark and finalizes the buckets that ended before the watermark of the
+// slowest active stream
+func (b *Batch) Finish(ctx context.Context) {
+	finalizer := buckets.NewFinalizer(b.registry, getScorer(b.registry), getPublisher(), getStateStore())
+
+	for bucketKey := range b.rescoreBuckets {
+		if _, err := finalizer.Refinalize(ctx, bucketKey); err != nil {
+			fmt.Printf("Error rescoring bucket %s: %v\n", bucketKey, err)
+			continue
+		}
+
+		b.stats.Rescored++
+	}
+
+	var watermark time.Time
+
+	if !b.latestEventTime.IsZero() {
+		minEventTime, err := b.minEventTime(ctx)
+
+		if err != nil {
+			fmt.Printf("Error advancing watermark: %v\n", err)
+		} else {
+			watermark = minEventTime.Add(-b.allowedLateness)
+
+			// Unfinalized buckets are picked up again by the next batch
+			if _, err := finalizer.FinalizeThrough(ctx, watermark); err != nil {
+				fmt.Printf("Error finalizing buckets: %v\n", err)
+			}
+		}
+	}
+
+	logBatchStats(b.stream, b.stats, watermark)
+	quality.EmitMetrics(b.stream, b.rejections)
+}
+
+// minEventTime advances the watermark of the stream and returns the event
+// time that every active stream has reached
+func (b *Batch) minEventTime(ctx context.Context) (time.Time, error) {
+	if err := buckets.AdvanceWatermark(ctx, b.stream, b.latestEventTime); err != nil {
+		return time.Time{}, err
+	}
+
+	return buckets.MinWatermark(ctx, b.idleTimeout)
+}
+
+// processRecord unpacks the readings of a record and processes each of them.
+// A failed reading fails the whole record; readings already stored are
+// skipped as duplicates when it is retried.
+func (b *Batch) processRecord(ctx context.Context, recordID string, payload []byte) error {
+	readings, err := b.decoder.Decode(payload)
+
+	if err != nil {
+		// Malformed payloads will never succeed on retry, so skip them
+		fmt.Printf("Cannot read telemetry data of record %s: %v\n", recordID, err)
+		b.stats.Malformed++
+		return nil
+	}
+
+	var errs []error
+
+	if err := b.trackPackets(ctx, readings); err != nil {
+		errs = append(errs, err)
+	}
+
+	for i, telemetryData := range readings {
+		readingID := recordID
+
+		if len(readings) > 1 {
+			readingID = fmt.Sprintf("%s#%d", recordID, i)
+		}
+
+		b.stats.Readings++
+
+		if err := b.processReading(ctx, readingID, telemetryData); err != nil {
+			errs = append(errs, fmt.Errorf("reading %d: %w", i, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// trackPackets checks the sequence counts of the CCSDS packets the readings
+// were decoded from. Each packet is claimed first, so a redelivered packet is
+// not reported as a sequence error.
+func (b *Batch) trackPackets(ctx context.Context, readings []types.TelemetryData) error {
+	tracked := map[types.PacketSequence]bool{}
+
+	for _, telemetryData := range readings {
+		if telemetryData.Packet == nil || tracked[*telemetryData.Packet] {
+			continue
+		}
+
+		sequence := *telemetryData.Packet
+		tracked[sequence] = true
+
+		dedupKey := b.deduplicator.PacketKey(sequence)
+		claimed, err := b.deduplicator.Claim(ctx, dedupKey)
+
+		if err != nil {
+			return err
+		}
+
+		if !claimed {
+			continue
+		}
+
+		event, err := ccsds.TrackSequence(ctx, getStateStore(), sequence)
+
+		if err != nil {
+			// Forget the packet so the retry tracks it again
+			if releaseErr := b.deduplicator.Release(ctx, dedupKey); releaseErr != nil {
+				fmt.Printf("Error releasing packet %s: %v\n", dedupKey, releaseErr)
+			}
+
+			return err
+		}
+
+		b.complete(ctx, dedupKey)
+
+		if event != nil {
+			ccsds.EmitDataLoss(*event)
+		}
+	}
+
+	return nil
+}
+
+// processReading validates a reading and stores it once, even when Lambda
+// delivers its record again
+func (b *Batch) processReading(ctx context.Context, readingID string, telemetryData types.TelemetryData) error {
+	// Readings named by PUI take the friendly name of the dictionary
+	b.dictionary.Enrich(&telemetryData)
+
+	channel, ok := b.registry.Get(telemetryData.Name)
+
+	if !ok {
+		return nil
+	}
+
+	if channel.IsDerived() {
+		fmt.Printf("Discarding reading of derived channel %s\n", telemetryData.Name)
+		return nil
+	}
+
+	rawValue := telemetryData.Value
+
+	// Enumerated items may carry their state label from the dictionary
+	if channel.IsDiscrete() && telemetryData.Label != "" {
+		rawValue = telemetryData.Label
+	}
+
+	// Readings that cannot be read are quarantined once they are claimed
+	var failure *quality.Failure
+
+	value, err := channel.ParseValue(rawValue)
+
+	if err != nil {
+		failure = &quality.Failure{Check: quality.PARSE, Reason: err.Error()}
+	} else if !channel.IsDiscrete() && channel.NeedsCalibration(telemetryData.Unit) {
+		calibrated, err := channel.Calibrate(value.Number, telemetryData.Unit)
+
+		if err != nil {
+			failure = &quality.Failure{Check: quality.CALIBRATION, Reason: err.Error()}
+		} else {
+			// Keep the reported value for traceability
+			telemetryData.RawValue = telemetryData.Value
+			telemetryData.RawUnit = telemetryData.Unit
+			telemetryData.Value = strconv.FormatFloat(calibrated, 'f', -1, 64)
+			telemetryData.Unit = channel.Unit
+			value.Number = calibrated
+		}
+	}
+
+	// Discrete values are buffered as their state name
+	if channel.IsDiscrete() && failure == nil {
+		telemetryData.Value = value.State
+	}
+
+	eventTime, err := timestamp.Parse(telemetryData.Timestamp, channel.TimestampFormat, time.Now())
+
+	if err != nil && failure == nil {
+		failure = &quality.Failure{Check: quality.TIMESTAMP, Reason: err.Error()}
+	}
+
+	// Everything downstream works with RFC3339 timestamps. Unreadable ones
+	// are quarantined as reported.
+	if err == nil {
+		telemetryData.Timestamp = timestamp.Normalize(eventTime)
+	}
+
+	dedupKey := b.deduplicator.Key(readingID, telemetryData)
+	claimed, err := b.deduplicator.Claim(ctx, dedupKey)
+
+	if err != nil {
+		return err
+	}
+
+	if !claimed {
+		b.stats.Duplicates++
+		return nil
+	}
+
+	if failure != nil {
+		err = b.quarantineReading(ctx, telemetryData, failure)
+	} else {
+		err = b.storeReading(ctx, telemetryData, channel, value, eventTime)
+	}
+
+	if err != nil {
+		// Forget the record so the retry is not taken for a duplicate
+		if releaseErr := b.deduplicator.Release(ctx, dedupKey); releaseErr != nil {
+			fmt.Printf("Error releasing record %s: %v\n", dedupKey, releaseErr)
+		}
+
+		return err
+	}
+
+	b.complete(ctx, dedupKey)
+
+	return nil
+}
+
+// complete marks a claimed key as processed. The reading is already stored,
+// so a failure is only logged: the lease then expires and a redelivery of the
+// record is processed again.
+func (b *Batch) complete(ctx context.Context, dedupKey string) {
+	if err := b.deduplicator.Complete(ctx, dedupKey); err != nil {
+		fmt.Printf("Error completing record %s: %v\n", dedupKey, err)
+	}
+}
+
+// storeReading checks the quality of a reading, buffers it and then updates
+// the sensor state, applying the late-data policy when its bucket was already
+// finalized. Readings failing a check are quarantined instead. The state only
+// changes once the reading is buffered, so a retried or late reading never
+// becomes the current one twice or out of order.
+func (b *Batch) storeReading(ctx context.Context, telemetryData types.TelemetryData, channel *channels.Channel, value channels.Value, eventTime time.Time) error {
+	if failure := quality.CheckValue(channel, value.Number); failure != nil {
+		return b.quarantineReading(ctx, telemetryData, failure)
+	}
+
+	telemetryData.Quality = string(quality.GOOD)
+
+	// Late readings are not compared with the newer readings of the state
+	if buckets.IsLate(eventTime, b.lastFinalized) {
+		return b.handleLateData(telemetryData, eventTime)
+	}
+
+	sensorState, err := getStateStore().Get(ctx, telemetryData.Name)
+
+	if err != nil {
+		return err
+	}
+
+	if failure := checkReading(channel, sensorState, value.Number, eventTime); failure != nil {
+		// The state is stored anyway so the checks remember the reading
+		_, err := state.Update(ctx, getStateStore(), telemetryData.Name, func(sensorState *state.SensorState) error {
+			failure = checkReading(channel, sensorState, value.Number, eventTime)
+			return nil
+		})
+
+		if err != nil {
+			return err
+		}
+
+		// A concurrent reading may have changed the outcome
+		if failure != nil {
+			return b.quarantineReading(ctx, telemetryData, failure)
+		}
+	}
+
+	err = dynamo.BufferData(telemetryData)
+
+	// The bucket was finalized since the batch started
+	if errors.Is(err, dynamo.ErrBucketFinalized) {
+		return b.handleLateData(telemetryData, eventTime)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// Shift the stored current reading to previous and keep the new one
+	_, err = state.Update(ctx, getStateStore(), telemetryData.Name, func(sensorState *state.SensorState) error {
+		if !isNewer(sensorState, eventTime) {
+			return nil
+		}
+
+		checkReading(channel, sensorState, value.Number, eventTime)
+		sensorState.Previous = sensorState.Current
+		sensorState.Current = &state.Reading{Value: value.Number, State: value.State, Timestamp: telemetryData.Timestamp}
+		return nil
+	})
+
+	// The reading is buffered, failing would buffer it again on retry
+	if err != nil {
+		fmt.Printf("Error updating state of %s: %v\n", telemetryData.Name, err)
+	}
+
+	// Only buffered readings move the watermark, so duplicates and
+	// quarantined readings cannot finalize buckets early
+	if eventTime.After(b.latestEventTime) {
+		b.latestEventTime = eventTime
+	}
+
+	return nil
+}
+
+// checkReading runs the checks comparing a reading with the state. Readings
+// older than the current one are not compared with it.
+func checkReading(channel *channels.Channel, sensorState *state.SensorState, value float64, eventTime time.Time) *quality.Failure {
+	if !isNewer(sensorState, eventTime) {
+		return nil
+	}
+
+	return quality.CheckReading(channel, sensorState, value, eventTime)
+}
+
+// isNewer reports whether a reading is newer than the current reading of the
+// state
+func isNewer(sensorState *state.SensorState, eventTime time.Time) bool {
+	if sensorState.Current == nil {
+		return true
+	}
+
+	current, err := time.Parse(time.RFC3339Nano, sensorState.Current.Timestamp)
+
+	return err != nil || eventTime.After(current)
+}
+
+// quarantineReading keeps a reading that failed a check out of the buckets
+func (b *Batch) quarantineReading(ctx context.Context, telemetryData types.TelemetryData, failure *quality.Failure) error {
+	fmt.Printf("Quarantining %s value %s: %v\n", telemetryData.Name, telemetryData.Value, failure)
+
+	if err := b.quarantine.Store(ctx, quality.NewRecord(telemetryData, failure)); err != nil {
+		return err
+	}
+
+	b.stats.Quarantined++
+	b.rejections[failure.Check]++
+
+	return nil
+}
+
+// handleLateData applies the late-data policy to a reading whose bucket was
+// already finalized
+func (b *Batch) handleLateData(telemetryData types.TelemetryData, eventTime time.Time) error {
+	b.stats.Late++
+	bucketKey := dynamo.BucketKey(eventTime)
+
+	switch b.latePolicy {
+	case buckets.RESCORE:
+		if err := dynamo.BufferLateData(telemetryData); err != nil {
+			return err
+		}
+
+		b.rescoreBuckets[bucketKey] = true
+
+	case buckets.DIVERT:
+		if err := dynamo.StoreLateData(telemetryData, bucketKey); err != nil {
+			return err
+		}
+
+		b.stats.Diverted++
+
+	default:
+		b.stats.Dropped++
+	}
+
+	return nil
+}
+
+func logBatchStats(stream string, stats batchStats, watermark time.Time) {
+	logData := map[string]interface{}{
+		"log_type": "invocation_stats",
+		"stream":   stream,
+		"stats":    stats,
+	}
+
+	if !watermark.IsZero() {
+		logData["watermark"] = watermark.Format(time.RFC3339)
+	}
+
+	logDataBytes, err := json.Marshal(logData)
+
+	if err != nil {
+		fmt.Printf("Error marshaling batch stats: %v\n", err)
+		return
+	}
+
+	fmt.Println(string(logDataBytes))
+}
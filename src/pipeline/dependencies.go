@@ -0,0 +1,126 @@
+package pipeline
+
+import (
+	"iss-telemetry-analyzer/src/channels"
+	"iss-telemetry-analyzer/src/dedup"
+	"iss-telemetry-analyzer/src/dictionary"
+	"iss-telemetry-analyzer/src/ingest"
+	"iss-telemetry-analyzer/src/quality"
+	"iss-telemetry-analyzer/src/scoring"
+	"iss-telemetry-analyzer/src/state"
+	"iss-telemetry-analyzer/src/websocket"
+	"sync"
+)
+
+// Dependencies are created once per Lambda instance and reused while warm
+
+var (
+	stateStore     state.Store
+	stateStoreOnce sync.Once
+)
+
+func getStateStore() state.Store {
+	stateStoreOnce.Do(func() {
+		stateStore = state.NewStoreFromEnv()
+	})
+
+	return stateStore
+}
+
+var (
+	registry     *channels.Registry
+	registryErr  error
+	registryOnce sync.Once
+)
+
+func getRegistry() (*channels.Registry, error) {
+	registryOnce.Do(func() {
+		registry, registryErr = channels.LoadFromEnv()
+	})
+
+	return registry, registryErr
+}
+
+var (
+	publisher     *websocket.Publisher
+	publisherOnce sync.Once
+)
+
+func getPublisher() *websocket.Publisher {
+	publisherOnce.Do(func() {
+		publisher = websocket.NewPublisher()
+	})
+
+	return publisher
+}
+
+var (
+	scorer     *scoring.Scorer
+	scorerOnce sync.Once
+)
+
+// getScorer keeps the scaler parameters and feature schema loaded while the
+// instance is warm. The registry is itself loaded once.
+func getScorer(registry *channels.Registry) *scoring.Scorer {
+	scorerOnce.Do(func() {
+		scorer = scoring.NewScorer(registry)
+	})
+
+	return scorer
+}
+
+var (
+	deduplicator     *dedup.Deduplicator
+	deduplicatorErr  error
+	deduplicatorOnce sync.Once
+)
+
+func getDeduplicator() (*dedup.Deduplicator, error) {
+	deduplicatorOnce.Do(func() {
+		deduplicator, deduplicatorErr = dedup.NewFromEnv()
+	})
+
+	return deduplicator, deduplicatorErr
+}
+
+var (
+	decoder     *ingest.Decoder
+	decoderErr  error
+	decoderOnce sync.Once
+)
+
+func getDecoder() (*ingest.Decoder, error) {
+	decoderOnce.Do(func() {
+		decoder, decoderErr = ingest.NewDecoderFromEnv()
+	})
+
+	return decoder, decoderErr
+}
+
+var (
+	telemetryDictionary     *dictionary.Dictionary
+	telemetryDictionaryErr  error
+	telemetryDictionaryOnce sync.Once
+)
+
+func getDictionary() (*dictionary.Dictionary, error) {
+	telemetryDictionaryOnce.Do(func() {
+		telemetryDictionary, telemetryDictionaryErr = dictionary.LoadFromEnv()
+	})
+
+	return telemetryDictionary, telemetryDictionaryErr
+}
+
+var (
+	quarantine     quality.Quarantine
+	quarantineErr  error
+	quarantineOnce sync.Once
+)
+
+func getQuarantine() (quality.Quarantine, error) {
+	quarantineOnce.Do(func() {
+		quarantine, quarantineErr = quality.NewQuarantineFromEnv()
+	})
+
+	return quarantine, quarantineErr
+}
@@ -0,0 +1,25 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"iss-telemetry-analyzer/src/buckets"
+)
+
+// FinalizeThrough closes the buckets that ended before the given time. It
+// lets scheduled runs finalize buckets while no records arrive.
+func FinalizeThrough(ctx context.Context, through time.Time) error {
+	registry, err := getRegistry()
+
+	if err != nil {
+		return fmt.Errorf("error loading channel registry: %w", err)
+	}
+
+	finalizer := buckets.NewFinalizer(registry, getScorer(registry), getPublisher(), getStateStore())
+
+	_, err = finalizer.FinalizeThrough(ctx, through)
+
+	return err
+}
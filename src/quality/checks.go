@@ -0,0 +1,109 @@
+package quality
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"iss-telemetry-analyzer/src/channels"
+	"iss-telemetry-analyzer/src/state"
+)
+
+// Check is a data-quality check a reading can fail
+type Check string
+
+const (
+	PARSE       Check = "parse"       // Not a value of the channel type
+	CALIBRATION Check = "calibration" // Cannot be calibrated, e.g. outside of the calibration table
+	TIMESTAMP   Check = "timestamp"   // Timestamp cannot be read
+	NAN         Check = "nan"         // Not a finite number
+	RANGE       Check = "range"       // Outside of the valid range of the channel
+	SPIKE       Check = "spike"       // Single reading far from the readings around it
+	FLATLINE    Check = "flatline"    // Sensor stuck at one value
+)
+
+// Checks lists every check in the order they are run
+var Checks = []Check{PARSE, CALIBRATION, TIMESTAMP, NAN, RANGE, SPIKE, FLATLINE}
+
+// Flag is the quality of a reading
+type Flag string
+
+const (
+	GOOD Flag = "good" // Passed every check and was buffered
+	BAD  Flag = "bad"  // Failed a check and was quarantined
+)
+
+// Failure is the check a reading failed and why
+type Failure struct {
+	Check  Check
+	Reason string
+}
+
+func (f *Failure) Error() string {
+	return fmt.Sprintf("%s check failed: %s", f.Check, f.Reason)
+}
+
+// CheckValue runs the checks that only need the value of a reading
+func CheckValue(channel *channels.Channel, value float64) *Failure {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return &Failure{Check: NAN, Reason: fmt.Sprintf("value %v is not a finite number", value)}
+	}
+
+	if !channel.InRange(value) {
+		return &Failure{Check: RANGE, Reason: fmt.Sprintf("value %v is outside of the valid range", value)}
+	}
+
+	return nil
+}
+
+// CheckReading runs the checks that compare a reading with the earlier
+// readings of its channel, before the reading becomes the current one. It
+// keeps what the checks need to remember in the sensor state, which must be
+// stored whether the reading passed or not.
+func CheckReading(channel *channels.Channel, sensorState *state.SensorState, value float64, eventTime time.Time) *Failure {
+	if channel.Quality == nil || channel.IsDiscrete() {
+		return nil
+	}
+
+	settings := channel.Quality
+	timestamp := eventTime.UTC().Format(time.RFC3339Nano)
+	current := sensorState.Current
+
+	if settings.MaxJump != nil && current != nil {
+		jump := math.Abs(value - current.Value)
+
+		// A later reading close to the rejected one confirms a change of
+		// level. The rejected reading itself is rejected again on retry.
+		spike := sensorState.Spike
+		confirmed := spike != nil && spike.Timestamp != timestamp && math.Abs(value-spike.Value) <= *settings.MaxJump
+
+		if jump > *settings.MaxJump && !confirmed {
+			sensorState.Spike = &state.Reading{Value: value, Timestamp: timestamp}
+
+			return &Failure{Check: SPIKE, Reason: fmt.Sprintf("jump of %v from %v exceeds %v", jump, current.Value, *settings.MaxJump)}
+		}
+	}
+
+	sensorState.Spike = nil
+
+	if current == nil || math.Abs(value-current.Value) > settings.FlatlineTolerance {
+		sensorState.UnchangedSince = timestamp
+		return nil
+	}
+
+	if sensorState.UnchangedSince == "" {
+		sensorState.UnchangedSince = current.Timestamp
+	}
+
+	since, err := time.Parse(time.RFC3339Nano, sensorState.UnchangedSince)
+
+	if err != nil || settings.FlatlineAfter == 0 {
+		return nil
+	}
+
+	if unchanged := eventTime.Sub(since); unchanged > time.Duration(settings.FlatlineAfter) {
+		return &Failure{Check: FLATLINE, Reason: fmt.Sprintf("value %v unchanged for %s", value, unchanged.Round(time.Second))}
+	}
+
+	return nil
+}
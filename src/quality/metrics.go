@@ -0,0 +1,44 @@
+package quality
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// metricsNamespace is the CloudWatch namespace of the rejection counts
+const metricsNamespace = "ISSTelemetry/DataQuality"
+
+// EmitMetrics logs the readings rejected by each check in the CloudWatch
+// embedded metric format, which CloudWatch turns into the Rejections metric
+// by Stream and Check. Checks without rejections are logged with zero so
+// that alarms see a continuous series.
+func EmitMetrics(stream string, rejections map[Check]int) {
+	timestamp := time.Now().UnixMilli()
+
+	for _, check := range Checks {
+		logData := map[string]interface{}{
+			"_aws": map[string]interface{}{
+				"Timestamp": timestamp,
+				"CloudWatchMetrics": []map[string]interface{}{{
+					"Namespace":  metricsNamespace,
+					"Dimensions": [][]string{{"Stream", "Check"}},
+					"Metrics":    []map[string]string{{"Name": "Rejections", "Unit": "Count"}},
+				}},
+			},
+			"log_type":   "quality_metrics",
+			"Stream":     stream,
+			"Check":      string(check),
+			"Rejections": rejections[check],
+		}
+
+		logDataBytes, err := json.Marshal(logData)
+
+		if err != nil {
+			fmt.Printf("Error marshaling quality metrics: %v\n", err)
+			continue
+		}
+
+		fmt.Println(string(logDataBytes))
+	}
+}
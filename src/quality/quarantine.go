@@ -0,0 +1,142 @@
+package quality
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"iss-telemetry-analyzer/src/dynamo"
+	"iss-telemetry-analyzer/src/types"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// Record is a reading that failed a check, with the reason attached
+type Record struct {
+	types.TelemetryData
+	Check         Check  `json:"check"`
+	Reason        string `json:"reason"`
+	QuarantinedAt string `json:"quarantined_at"`
+}
+
+// Quarantine keeps the readings that failed a check for later inspection
+type Quarantine interface {
+	Store(ctx context.Context, record Record) error
+}
+
+// NewQuarantineFromEnv builds the quarantine selected by QUARANTINE_STORE
+// ("dynamodb" by default, or "s3")
+func NewQuarantineFromEnv() (Quarantine, error) {
+	switch store := os.Getenv("QUARANTINE_STORE"); store {
+	case "", "dynamodb":
+		tableName := os.Getenv("QUARANTINE_TABLE")
+
+		if tableName == "" {
+			tableName = "TelemetryQuarantine"
+		}
+
+		return &DynamoQuarantine{client: dynamo.GetDynamoDBClient(), tableName: tableName}, nil
+
+	case "s3":
+		bucketName := os.Getenv("S3_BUCKET_NAME")
+
+		if bucketName == "" {
+			return nil, fmt.Errorf("S3_BUCKET_NAME environment variable is not set")
+		}
+
+		prefix := os.Getenv("QUARANTINE_PREFIX")
+
+		if prefix == "" {
+			prefix = "quarantine/"
+		}
+
+		sess := session.Must(session.NewSession(&aws.Config{
+			Region: aws.String("eu-west-1"),
+		}))
+
+		return &S3Quarantine{client: s3.New(sess), bucketName: bucketName, prefix: prefix}, nil
+
+	default:
+		return nil, fmt.Errorf("invalid QUARANTINE_STORE %q", store)
+	}
+}
+
+// DynamoQuarantine keeps quarantined readings in a DynamoDB table keyed by
+// name and quarantined_at
+type DynamoQuarantine struct {
+	client    *dynamodb.DynamoDB
+	tableName string
+}
+
+func (d *DynamoQuarantine) Store(ctx context.Context, record Record) error {
+	item, err := dynamodbattribute.MarshalMap(record)
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal quarantined reading: %w", err)
+	}
+
+	_, err = d.client.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(d.tableName),
+		Item:      item,
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to save quarantined reading to DynamoDB: %w", err)
+	}
+
+	return nil
+}
+
+// S3Quarantine keeps quarantined readings as JSON objects under a prefix,
+// one folder per channel
+type S3Quarantine struct {
+	client     *s3.S3
+	bucketName string
+	prefix     string
+}
+
+func (s *S3Quarantine) Store(ctx context.Context, record Record) error {
+	body, err := json.Marshal(record)
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal quarantined reading: %w", err)
+	}
+
+	// Readings of one channel may share a timestamp, the time of quarantine
+	// keeps their keys apart
+	key := fmt.Sprintf("%s%s/%s_%s.json", s.prefix, record.Name,
+		strings.ReplaceAll(record.Timestamp, ":", ""), strings.ReplaceAll(record.QuarantinedAt, ":", ""))
+
+	_, err = s.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucketName),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String("application/json"),
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to save quarantined reading to s3://%s/%s: %w", s.bucketName, key, err)
+	}
+
+	return nil
+}
+
+// NewRecord attaches a failure to a reading
+func NewRecord(telemetryData types.TelemetryData, failure *Failure) Record {
+	telemetryData.Quality = string(BAD)
+
+	return Record{
+		TelemetryData: telemetryData,
+		Check:         failure.Check,
+		Reason:        failure.Reason,
+		QuarantinedAt: time.Now().UTC().Format(time.RFC3339Nano),
+	}
+}
@@ -0,0 +1,176 @@
+package resample
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Method is how a channel is interpolated between its samples
+type Method string
+
+const (
+	ZERO_ORDER_HOLD Method = "zoh"     // Latest sample at or before the point
+	LINEAR          Method = "linear"  // Straight line between the samples around the point
+	NEAREST         Method = "nearest" // Sample closest in time to the point
+)
+
+// ParseMethod returns the method with the given name, zero-order hold when
+// the name is empty
+func ParseMethod(name string) (Method, error) {
+	method := Method(strings.ToLower(name))
+
+	switch method {
+	case "":
+		return ZERO_ORDER_HOLD, nil
+	case ZERO_ORDER_HOLD, LINEAR, NEAREST:
+		return method, nil
+	default:
+		return "", fmt.Errorf("unknown interpolation method %q", name)
+	}
+}
+
+// Sample is a reading of a channel
+type Sample struct {
+	Time  time.Time
+	Value float64
+}
+
+// Settings control how a channel is resampled
+type Settings struct {
+	Method Method
+	// Longest gap between samples that is interpolated over. Zero means no
+	// limit.
+	MaxGap time.Duration
+}
+
+// Point is the value of a channel at a grid time
+type Point struct {
+	Value   float64
+	Missing bool // No sample close enough to interpolate from
+}
+
+// Frame holds the points of all channels at one grid time
+type Frame struct {
+	Time   time.Time
+	Points map[string]Point
+}
+
+// Grid returns the times from start, included, to end, excluded, one
+// period apart
+func Grid(start time.Time, end time.Time, period time.Duration) []time.Time {
+	var grid []time.Time
+
+	if period <= 0 {
+		return grid
+	}
+
+	for t := start; t.Before(end); t = t.Add(period) {
+		grid = append(grid, t)
+	}
+
+	return grid
+}
+
+// Frames resamples every channel onto the grid
+func Frames(series map[string][]Sample, grid []time.Time, settings map[string]Settings) []Frame {
+	frames := make([]Frame, len(grid))
+
+	for i, t := range grid {
+		frames[i] = Frame{Time: t, Points: map[string]Point{}}
+	}
+
+	for channel, samples := range series {
+		for i, point := range Series(samples, grid, settings[channel]) {
+			frames[i].Points[channel] = point
+		}
+	}
+
+	return frames
+}
+
+// Series resamples the samples of one channel onto the grid. Past the latest
+// sample, linear interpolation holds its value like zero-order hold.
+func Series(samples []Sample, grid []time.Time, settings Settings) []Point {
+	sorted := make([]Sample, len(samples))
+	copy(sorted, samples)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	points := make([]Point, len(grid))
+
+	for i, t := range grid {
+		points[i] = interpolate(sorted, t, settings)
+	}
+
+	return points
+}
+
+func interpolate(samples []Sample, t time.Time, settings Settings) Point {
+	// First sample after t; the one before it is the latest at or before t
+	after := sort.Search(len(samples), func(i int) bool { return samples[i].Time.After(t) })
+
+	var before *Sample
+	var next *Sample
+
+	if after > 0 {
+		before = &samples[after-1]
+	}
+
+	if after < len(samples) {
+		next = &samples[after]
+	}
+
+	withinGap := func(gap time.Duration) bool {
+		return settings.MaxGap <= 0 || gap <= settings.MaxGap
+	}
+
+	switch settings.Method {
+	case NEAREST:
+		nearest := before
+
+		if nearest == nil || (next != nil && next.Time.Sub(t) < t.Sub(nearest.Time)) {
+			nearest = next
+		}
+
+		if nearest == nil || !withinGap(absDuration(nearest.Time.Sub(t))) {
+			return Point{Missing: true}
+		}
+
+		return Point{Value: nearest.Value}
+
+	case LINEAR:
+		if before != nil && next != nil {
+			gap := next.Time.Sub(before.Time)
+
+			if before.Time.Equal(t) {
+				return Point{Value: before.Value}
+			}
+
+			if !withinGap(gap) {
+				return Point{Missing: true}
+			}
+
+			fraction := float64(t.Sub(before.Time)) / float64(gap)
+
+			return Point{Value: before.Value + fraction*(next.Value-before.Value)}
+		}
+
+		fallthrough
+
+	default:
+		if before == nil || !withinGap(t.Sub(before.Time)) {
+			return Point{Missing: true}
+		}
+
+		return Point{Value: before.Value}
+	}
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+
+	return d
+}
@@ -0,0 +1,99 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Source is an event shape the lambda can handle
+type Source struct {
+	Name string
+	// Sources are detected in ascending priority, so more specific shapes
+	// should use lower values
+	Priority int
+	Detect   func(event json.RawMessage) bool
+	Handle   func(ctx context.Context, event json.RawMessage) (interface{}, error)
+}
+
+var (
+	mu      sync.RWMutex
+	sources []Source
+)
+
+// Register adds an event source. Sources register themselves from init.
+func Register(source Source) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, registered := range sources {
+		if registered.Name == source.Name {
+			panic(fmt.Sprintf("event source %s registered twice", source.Name))
+		}
+	}
+
+	sources = append(sources, source)
+
+	sort.SliceStable(sources, func(i, j int) bool {
+		return sources[i].Priority < sources[j].Priority
+	})
+}
+
+// Route hands the event to the first source recognising it
+func Route(ctx context.Context, event json.RawMessage) (interface{}, error) {
+	source, err := detect(event)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return source.Handle(ctx, event)
+}
+
+func detect(event json.RawMessage) (*Source, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for i := range sources {
+		if sources[i].Detect(event) {
+			return &sources[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown event type")
+}
+
+// Typed adapts a handler of a concrete event type to a Source handler
+func Typed[T any, R any](handle func(context.Context, T) (R, error)) func(context.Context, json.RawMessage) (interface{}, error) {
+	return func(ctx context.Context, event json.RawMessage) (interface{}, error) {
+		var typedEvent T
+
+		if err := json.Unmarshal(event, &typedEvent); err != nil {
+			fmt.Printf("Error unmarshalling %T: %v\n", typedEvent, err)
+			return nil, err
+		}
+
+		return handle(ctx, typedEvent)
+	}
+}
+
+// recordsEnvelope is the shape shared by Kinesis, SQS and S3 events
+type recordsEnvelope struct {
+	Records []struct {
+		EventSource string `json:"eventSource"`
+	} `json:"Records"`
+}
+
+// RecordsFrom reports whether the event is a batch of records from the
+// given event source, e.g. "aws:kinesis"
+func RecordsFrom(event json.RawMessage, eventSource string) bool {
+	var envelope recordsEnvelope
+
+	if err := json.Unmarshal(event, &envelope); err != nil || len(envelope.Records) == 0 {
+		return false
+	}
+
+	return envelope.Records[0].EventSource == eventSource
+}
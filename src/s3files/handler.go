@@ -0,0 +1,88 @@
+package s3files
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"iss-telemetry-analyzer/src/pipeline"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// maxLineSize bounds a single telemetry record in an ingested file
+const maxLineSize = 1024 * 1024
+
+// Handler ingests telemetry files uploaded to S3. Files hold one telemetry
+// record per line. Returning an error makes Lambda retry the whole event;
+// records already ingested are then skipped by the deduplicator.
+func Handler(ctx context.Context, s3Event events.S3Event) error {
+	sess := session.Must(session.NewSession(&aws.Config{
+		Region: aws.String("eu-west-1"),
+	}))
+	svc := s3.New(sess)
+
+	var errs []error
+
+	for _, record := range s3Event.Records {
+		if !strings.HasPrefix(record.EventName, "ObjectCreated") {
+			continue
+		}
+
+		if err := ingestObject(ctx, svc, record.S3.Bucket.Name, record.S3.Object.URLDecodedKey); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func ingestObject(ctx context.Context, svc *s3.S3, bucketName string, key string) error {
+	result, err := svc.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to download s3://%s/%s: %w", bucketName, key, err)
+	}
+	defer result.Body.Close()
+
+	batch, err := pipeline.NewBatch(ctx, "s3://"+bucketName)
+
+	if err != nil {
+		return err
+	}
+
+	defer batch.Finish(ctx)
+
+	scanner := bufio.NewScanner(result.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	var errs []error
+	recordID := "s3://" + bucketName + "/" + key + "#"
+
+	for line := 1; scanner.Scan(); line++ {
+		payload := scanner.Bytes()
+
+		if len(strings.TrimSpace(string(payload))) == 0 {
+			continue
+		}
+
+		if err := batch.Ingest(ctx, recordID+strconv.Itoa(line), payload); err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", line, err))
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to read s3://%s/%s: %w", bucketName, key, err))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,22 @@
+package s3files
+
+import (
+	"context"
+	"encoding/json"
+	"iss-telemetry-analyzer/src/router"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func init() {
+	router.Register(router.Source{
+		Name:     "s3",
+		Priority: 20,
+		Detect: func(event json.RawMessage) bool {
+			return router.RecordsFrom(event, "aws:s3")
+		},
+		Handle: router.Typed(func(ctx context.Context, s3Event events.S3Event) (interface{}, error) {
+			return nil, Handler(ctx, s3Event)
+		}),
+	})
+}
@@ -3,7 +3,7 @@ package sagemaker
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"fmt"
 	"os"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -17,19 +17,20 @@ type SageMakerResponse struct {
 	} `json:"scores"`
 }
 
-func Predict(values []float64) float64 {
+// Predict returns the anomaly score of a scaled feature vector
+func Predict(ctx context.Context, values []float64) (float64, error) {
 	// Read endpoint name from environment variable
 	endpointName := os.Getenv("SAGEMAKER_ENDPOINT_NAME")
 
 	if endpointName == "" {
-		log.Fatal("Could not get sagemaker endpoint")
+		return 0, fmt.Errorf("SAGEMAKER_ENDPOINT_NAME environment variable is not set")
 	}
 
 	// Load AWS config with eu-west-1 region
-	cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion("eu-west-1"))
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion("eu-west-1"))
 
 	if err != nil {
-		log.Fatalf("unable to load AWS config: %v", err)
+		return 0, fmt.Errorf("unable to load AWS config: %w", err)
 	}
 
 	// Create SageMaker Runtime client
@@ -42,10 +43,14 @@ func Predict(values []float64) float64 {
 		},
 	}
 
-	payloadBytes, _ := json.Marshal(payload)
+	payloadBytes, err := json.Marshal(payload)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal features: %w", err)
+	}
 
 	// Invoke SageMaker endpoint
-	output, err := client.InvokeEndpoint(context.TODO(), &sagemakerruntime.InvokeEndpointInput{
+	output, err := client.InvokeEndpoint(ctx, &sagemakerruntime.InvokeEndpointInput{
 		EndpointName: &endpointName,
 		Body:         payloadBytes,
 		ContentType:  aws.String("application/json"),
@@ -53,7 +58,7 @@ func Predict(values []float64) float64 {
 	})
 
 	if err != nil {
-		log.Fatalf("failed to invoke endpoint: %v", err)
+		return 0, fmt.Errorf("failed to invoke endpoint %s: %w", endpointName, err)
 	}
 
 	var response SageMakerResponse
@@ -61,10 +66,12 @@ func Predict(values []float64) float64 {
 	err = json.Unmarshal(output.Body, &response)
 
 	if err != nil {
-		log.Fatalf("failed to parse response: %v", err)
+		return 0, fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	score := response.Scores[0].Score
+	if len(response.Scores) == 0 {
+		return 0, fmt.Errorf("response of endpoint %s has no score", endpointName)
+	}
 
-	return score
+	return response.Scores[0].Score, nil
 }
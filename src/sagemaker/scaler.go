@@ -13,8 +13,9 @@ import (
 
 // RobustScalerParams holds the parameters for the robust scaler
 type RobustScalerParams struct {
-	Medians []float64 `json:"center"` // Updated field name to match JSON
-	IQRs    []float64 `json:"scale"`  // Updated field name to match JSON
+	Medians      []float64 `json:"center"`                  // Updated field name to match JSON
+	IQRs         []float64 `json:"scale"`                   // Updated field name to match JSON
+	FeatureNames []string  `json:"feature_names,omitempty"` // Optional, in fitting order
 }
 
 // LoadRobustScalerParams loads the robust scaler parameters from S3
@@ -58,10 +59,10 @@ func LoadRobustScalerParams() (*RobustScalerParams, error) {
 }
 
 // RobustScale applies robust scaling to the features
-func RobustScale(features []float64, params *RobustScalerParams) []float64 {
-	if len(features) != len(params.Medians) {
-		// Handle error: feature vector length doesn't match parameters
-		return features
+func RobustScale(features []float64, params *RobustScalerParams) ([]float64, error) {
+	if len(features) != len(params.Medians) || len(features) != len(params.IQRs) {
+		return nil, fmt.Errorf("feature vector has %d values but scaler has %d centers and %d scales",
+			len(features), len(params.Medians), len(params.IQRs))
 	}
 
 	scaledFeatures := make([]float64, len(features))
@@ -74,5 +75,5 @@ func RobustScale(features []float64, params *RobustScalerParams) []float64 {
 		}
 	}
 
-	return scaledFeatures
+	return scaledFeatures, nil
 }
@@ -0,0 +1,69 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Job is a periodic task run on EventBridge scheduled events
+type Job func(ctx context.Context, now time.Time) error
+
+// jobDetail optionally restricts a scheduled event to some jobs, using a
+// rule input such as {"detail": {"jobs": ["finalize"]}}
+type jobDetail struct {
+	Jobs []string `json:"jobs"`
+}
+
+// jobOrder is the order in which jobs run when an event selects all of them
+var jobOrder = []string{"finalize", "cleanup"}
+
+var jobs = map[string]Job{
+	"finalize": finalizeJob,
+	"cleanup":  cleanupJob,
+}
+
+// Handler runs the scheduled jobs and reports the outcome of each
+func Handler(ctx context.Context, event events.EventBridgeEvent) (map[string]string, error) {
+	selected := jobOrder
+
+	var detail jobDetail
+
+	if len(event.Detail) > 0 && json.Unmarshal(event.Detail, &detail) == nil && len(detail.Jobs) > 0 {
+		selected = detail.Jobs
+	}
+
+	now := event.Time
+
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	results := map[string]string{}
+	var errs []error
+
+	for _, name := range selected {
+		job, ok := jobs[name]
+
+		if !ok {
+			errs = append(errs, fmt.Errorf("unknown job %s", name))
+			results[name] = "unknown job"
+			continue
+		}
+
+		if err := job(ctx, now.UTC()); err != nil {
+			fmt.Printf("Error running job %s: %v\n", name, err)
+			errs = append(errs, fmt.Errorf("job %s: %w", name, err))
+			results[name] = err.Error()
+			continue
+		}
+
+		results[name] = "ok"
+	}
+
+	return results, errors.Join(errs...)
+}
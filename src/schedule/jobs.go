@@ -0,0 +1,53 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"iss-telemetry-analyzer/src/buckets"
+	"iss-telemetry-analyzer/src/dynamo"
+	"iss-telemetry-analyzer/src/pipeline"
+	"iss-telemetry-analyzer/src/websocket"
+)
+
+// defaultBucketRetention is how long raw buckets are kept by default
+const defaultBucketRetention = 7 * 24 * time.Hour
+
+// finalizeJob closes buckets by wall-clock time, so data keeps being scored
+// and loss of signal is reported even when no records arrive
+func finalizeJob(ctx context.Context, now time.Time) error {
+	allowedLateness, err := buckets.AllowedLatenessFromEnv()
+
+	if err != nil {
+		return err
+	}
+
+	return pipeline.FinalizeThrough(ctx, now.Add(-allowedLateness))
+}
+
+// cleanupJob deletes raw buckets older than BUCKET_RETENTION and WebSocket
+// connections API Gateway has already closed
+func cleanupJob(ctx context.Context, now time.Time) error {
+	retention := defaultBucketRetention
+
+	if value := os.Getenv("BUCKET_RETENTION"); value != "" {
+		parsed, err := time.ParseDuration(value)
+
+		if err != nil || parsed <= 0 {
+			return fmt.Errorf("invalid BUCKET_RETENTION %q", value)
+		}
+
+		retention = parsed
+	}
+
+	deleted, bucketsErr := dynamo.DeleteBucketsBefore(now.Add(-retention))
+	fmt.Printf("Deleted %d telemetry buckets older than %s\n", deleted, retention)
+
+	pruned, connectionsErr := websocket.PruneConnections(ctx, now)
+	fmt.Printf("Pruned %d expired WebSocket connections\n", pruned)
+
+	return errors.Join(bucketsErr, connectionsErr)
+}
@@ -0,0 +1,22 @@
+package schedule
+
+import (
+	"encoding/json"
+	"iss-telemetry-analyzer/src/router"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func init() {
+	router.Register(router.Source{
+		Name:     "schedule",
+		Priority: 30,
+		Detect: func(event json.RawMessage) bool {
+			var scheduledEvent events.EventBridgeEvent
+
+			return json.Unmarshal(event, &scheduledEvent) == nil &&
+				scheduledEvent.Source == "aws.events" && scheduledEvent.DetailType == "Scheduled Event"
+		},
+		Handle: router.Typed(Handler),
+	})
+}
@@ -0,0 +1,134 @@
+package scoring
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"iss-telemetry-analyzer/src/channels"
+	"iss-telemetry-analyzer/src/dynamo"
+	"iss-telemetry-analyzer/src/features"
+	"iss-telemetry-analyzer/src/sagemaker"
+	"iss-telemetry-analyzer/src/types"
+	"iss-telemetry-analyzer/src/utils"
+)
+
+// Scorer runs processed data through the anomaly model. The scaler
+// parameters and feature schema are loaded on first use and kept, so one
+// scorer is shared by the invocations of a warm instance.
+type Scorer struct {
+	registry     *channels.Registry
+	mu           sync.Mutex
+	scalerParams *sagemaker.RobustScalerParams
+	schema       *features.Schema
+}
+
+func NewScorer(registry *channels.Registry) *Scorer {
+	return &Scorer{registry: registry}
+}
+
+// load loads the model inputs once. Failed loads are retried on the next call.
+func (s *Scorer) load() (*sagemaker.RobustScalerParams, *features.Schema, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.schema != nil {
+		return s.scalerParams, s.schema, nil
+	}
+
+	scalerParams, err := sagemaker.LoadRobustScalerParams()
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("error loading scaler parameters: %w", err)
+	}
+
+	schema, err := features.LoadSchema(s.registry)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := schema.Validate(scalerParams); err != nil {
+		return nil, nil, fmt.Errorf("feature schema does not match the model: %w", err)
+	}
+
+	s.scalerParams = scalerParams
+	s.schema = schema
+
+	return scalerParams, schema, nil
+}
+
+// Score sets the anomaly score and level of the processed data and emits it
+func (s *Scorer) Score(ctx context.Context, processedData *types.ProcessedData) error {
+	scalerParams, schema, err := s.load()
+
+	if err != nil {
+		return err
+	}
+
+	featureVector, err := schema.Build(features.FromProcessedData(s.registry, *processedData))
+
+	if err != nil {
+		return err
+	}
+
+	scaledFeatures, err := sagemaker.RobustScale(featureVector, scalerParams)
+
+	if err != nil {
+		return err
+	}
+
+	anomalyScore, err := sagemaker.Predict(ctx, scaledFeatures)
+
+	if err != nil {
+		return err
+	}
+
+	scoreResult := dynamo.StoreAnomalyScore(processedData.Timestamp, anomalyScore)
+
+	if scoreResult.Error != nil {
+		fmt.Println("STORE ERRORS: ", scoreResult.Error)
+	}
+
+	processedData.AnomalyScore = anomalyScore
+	processedData.AnomalyLevel = utils.ComputeAnomalyLevel(anomalyScore, scoreResult.StandardDeviation, scoreResult.Average).String()
+	processedData.Scored = true
+
+	Emit(*processedData, &scoreResult)
+
+	return nil
+}
+
+// Emit logs the processed data as structured JSON for querying in Grafana.
+// The score statistics are nil when the data was not scored.
+func Emit(processedData types.ProcessedData, scoreResult *types.StoreAnomalyScoreResult) {
+	logData := map[string]interface{}{
+		"timestamp": processedData.Timestamp,
+		"channels":  processedData.Channels,
+		"scored":    processedData.Scored,
+		"log_type":  "telemetry_data",
+	}
+
+	if scoreResult != nil {
+		// Add random deviation to upper and lower anomaly score deviation limits
+		upperLimit := scoreResult.Average + 3*scoreResult.StandardDeviation + 0
+		lowerLimit := scoreResult.Average - 3*scoreResult.StandardDeviation - 0
+
+		logData["anomaly_score"] = processedData.AnomalyScore
+		logData["anomaly_level"] = processedData.AnomalyLevel
+		logData["moving_avg_score"] = scoreResult.Average
+		logData["moving_avg_std"] = scoreResult.StandardDeviation
+		logData["upper_anomaly_score_deviation_limit"] = upperLimit
+		logData["lower_anomaly_score_deviation_limit"] = lowerLimit
+	}
+
+	logDataBytes, err := json.Marshal(logData)
+
+	if err != nil {
+		fmt.Printf("Error marshaling log data: %v\n", err)
+	} else {
+		// Print log data as JSON for CloudWatch and Grafana to query
+		fmt.Println(string(logDataBytes))
+	}
+}
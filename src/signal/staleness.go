@@ -0,0 +1,94 @@
+package signal
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"iss-telemetry-analyzer/src/channels"
+	"iss-telemetry-analyzer/src/types"
+)
+
+type EventType string
+
+const (
+	LOSS_OF_SIGNAL   EventType = "LOSS_OF_SIGNAL"
+	SIGNAL_RECOVERED EventType = "SIGNAL_RECOVERED"
+)
+
+// Event reports a channel going stale or resuming
+type Event struct {
+	Type      EventType `json:"type"`
+	Channel   string    `json:"channel"`
+	Timestamp string    `json:"timestamp"` // Start of the bucket where it was detected
+	LastSeen  string    `json:"last_seen"`
+	Age       float64   `json:"age_seconds"`
+}
+
+// CheckStaleness flags the channels of a bucket whose latest reading is older
+// than their max age at the end of the bucket, and returns the channels
+// whose staleness changed since the previous bucket
+func CheckStaleness(registry *channels.Registry, processedData *types.ProcessedData, previous *types.ProcessedData, bucketEnd time.Time) []Event {
+	var events []Event
+
+	for name, channelValue := range processedData.Channels {
+		channel, ok := registry.Get(name)
+
+		if !ok {
+			continue
+		}
+
+		lastSeen, err := time.Parse(time.RFC3339, channelValue.LastSeen)
+
+		if err != nil {
+			continue
+		}
+
+		age := bucketEnd.Sub(lastSeen)
+		channelValue.Stale = channel.IsStale(age)
+		processedData.Channels[name] = channelValue
+
+		wasStale := false
+
+		if previous != nil {
+			wasStale = previous.Channels[name].Stale
+		}
+
+		if channelValue.Stale == wasStale {
+			continue
+		}
+
+		event := Event{
+			Type:      LOSS_OF_SIGNAL,
+			Channel:   name,
+			Timestamp: processedData.Timestamp,
+			LastSeen:  channelValue.LastSeen,
+			Age:       age.Seconds(),
+		}
+
+		if !channelValue.Stale {
+			event.Type = SIGNAL_RECOVERED
+		}
+
+		events = append(events, event)
+	}
+
+	return events
+}
+
+// Emit logs a signal event as structured JSON
+func Emit(event Event) {
+	logData := map[string]interface{}{
+		"log_type": "signal_event",
+		"event":    event,
+	}
+
+	logDataBytes, err := json.Marshal(logData)
+
+	if err != nil {
+		fmt.Printf("Error marshaling signal event: %v\n", err)
+		return
+	}
+
+	fmt.Println(string(logDataBytes))
+}
@@ -0,0 +1,12 @@
+// Package sources links every event source into the binary. Each source
+// registers itself with the router, so adding one only takes a new import.
+package sources
+
+import (
+	_ "iss-telemetry-analyzer/src/api"
+	_ "iss-telemetry-analyzer/src/kinesis"
+	_ "iss-telemetry-analyzer/src/s3files"
+	_ "iss-telemetry-analyzer/src/schedule"
+	_ "iss-telemetry-analyzer/src/sqs"
+	_ "iss-telemetry-analyzer/src/websocket"
+)
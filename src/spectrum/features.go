@@ -0,0 +1,195 @@
+package spectrum
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"iss-telemetry-analyzer/src/window"
+)
+
+// Keys of the spectral features
+const (
+	DOMINANT_FREQUENCY = "dominant_frequency" // Frequency of the strongest bin, in Hz
+	SPECTRAL_CENTROID  = "spectral_centroid"  // Power-weighted mean frequency, in Hz
+	SPECTRAL_ENTROPY   = "spectral_entropy"   // Flatness of the spectrum, from 0 for a pure tone to 1 for white noise
+)
+
+// Defaults of the optional settings
+const (
+	defaultSegment = 32
+	minSegment     = 4
+	maxSegment     = 1024
+)
+
+// Band is a frequency range whose power is a feature
+type Band struct {
+	Name string  `json:"name" yaml:"name"`
+	Low  float64 `json:"low" yaml:"low"`   // Lowest frequency in Hz, included
+	High float64 `json:"high" yaml:"high"` // Highest frequency in Hz, excluded
+}
+
+// Settings tune the spectrum of a channel
+type Settings struct {
+	Segment int    // Values per Welch segment, a power of two
+	Bands   []Band // Bands whose power is reported
+}
+
+// Validate checks the settings and fills in the defaults
+func (s *Settings) Validate() error {
+	if s.Segment == 0 {
+		s.Segment = defaultSegment
+	}
+
+	if !isPowerOfTwo(s.Segment) || s.Segment < minSegment || s.Segment > maxSegment {
+		return fmt.Errorf("spectrum segment must be a power of two between %d and %d values", minSegment, maxSegment)
+	}
+
+	names := map[string]bool{}
+
+	for _, band := range s.Bands {
+		if band.Name == "" || strings.ContainsAny(band.Name, ". ") {
+			return fmt.Errorf("spectrum band %q must have a name without dots or spaces", band.Name)
+		}
+
+		if names[band.Name] {
+			return fmt.Errorf("spectrum band %s is declared twice", band.Name)
+		}
+
+		names[band.Name] = true
+
+		if band.Low < 0 || band.High <= band.Low {
+			return fmt.Errorf("spectrum band %s must have 0 <= low < high", band.Name)
+		}
+	}
+
+	return nil
+}
+
+// BandKey names the power feature of a band, e.g. band_power_pump
+func BandKey(band string) string {
+	return "band_power_" + band
+}
+
+// Keys lists the spectral features in the order they are laid out
+func (s *Settings) Keys() []string {
+	keys := []string{DOMINANT_FREQUENCY, SPECTRAL_CENTROID, SPECTRAL_ENTROPY}
+
+	for _, band := range s.Bands {
+		keys = append(keys, BandKey(band.Name))
+	}
+
+	return keys
+}
+
+// Compute extracts the spectral features of the points within length of the
+// newest point, sampled every step. Missing steps repeat the value before
+// them. Shorter segments are used until enough values were seen, and nothing
+// is computed with fewer than the smallest segment.
+func Compute(points []window.Point, length time.Duration, step time.Duration, settings Settings) map[string]float64 {
+	values := evenlySpaced(points, length, step)
+	segment := settings.Segment
+
+	for segment > len(values) && segment > minSegment {
+		segment /= 2
+	}
+
+	frequencies, density := Welch(values, 1/step.Seconds(), segment)
+
+	if frequencies == nil {
+		return nil
+	}
+
+	features := map[string]float64{}
+	binWidth := frequencies[1] - frequencies[0]
+	total, weighted, strongest := 0.0, 0.0, 0
+
+	// The zero-frequency bin only holds what is left of the removed mean
+	for k := 1; k < len(density); k++ {
+		total += density[k]
+		weighted += frequencies[k] * density[k]
+
+		if strongest == 0 || density[k] > density[strongest] {
+			strongest = k
+		}
+	}
+
+	features[DOMINANT_FREQUENCY] = 0
+	features[SPECTRAL_CENTROID] = 0
+	features[SPECTRAL_ENTROPY] = 0
+
+	if total > 0 {
+		features[DOMINANT_FREQUENCY] = frequencies[strongest]
+		features[SPECTRAL_CENTROID] = weighted / total
+		features[SPECTRAL_ENTROPY] = entropy(density[1:], total)
+	}
+
+	for _, band := range settings.Bands {
+		power := 0.0
+
+		for k, frequency := range frequencies {
+			if frequency >= band.Low && frequency < band.High {
+				power += density[k] * binWidth
+			}
+		}
+
+		features[BandKey(band.Name)] = power
+	}
+
+	return features
+}
+
+// entropy is the Shannon entropy of the normalised spectrum, divided by its
+// largest possible value
+func entropy(density []float64, total float64) float64 {
+	if len(density) < 2 {
+		return 0
+	}
+
+	sum := 0.0
+
+	for _, value := range density {
+		if p := value / total; p > 0 {
+			sum -= p * math.Log(p)
+		}
+	}
+
+	return sum / math.Log(float64(len(density)))
+}
+
+// evenlySpaced lays the points within length of the newest one on a grid of
+// the given step, ending with the newest point
+func evenlySpaced(points []window.Point, length time.Duration, step time.Duration) []float64 {
+	if len(points) == 0 || step < time.Second {
+		return nil
+	}
+
+	stepSeconds := int64(step / time.Second)
+	newest := points[len(points)-1].Time
+	start := newest - int64(length/time.Second)
+	first := len(points) - 1
+
+	for first > 0 && points[first-1].Time > start {
+		first--
+	}
+
+	var values []float64
+	next := points[first].Time
+
+	for i := first; i < len(points); i++ {
+		// Hold the last value over missing steps
+		for ; next < points[i].Time && len(values) > 0; next += stepSeconds {
+			values = append(values, values[len(values)-1])
+		}
+
+		if points[i].Time < next {
+			continue
+		}
+
+		values = append(values, points[i].Value)
+		next = points[i].Time + stepSeconds
+	}
+
+	return values
+}
@@ -0,0 +1,49 @@
+package spectrum
+
+import (
+	"math"
+	"math/bits"
+)
+
+// fft transforms values in place with the iterative radix-2 Cooley-Tukey
+// algorithm. Their count must be a power of two.
+func fft(values []complex128) {
+	n := len(values)
+
+	if n < 2 {
+		return
+	}
+
+	// Reorder by bit-reversed index so that butterflies work on neighbours
+	shift := 64 - bits.TrailingZeros(uint(n))
+
+	for i := range values {
+		j := int(bits.Reverse64(uint64(i)) >> shift)
+
+		if i < j {
+			values[i], values[j] = values[j], values[i]
+		}
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		angle := -2 * math.Pi / float64(size)
+		step := complex(math.Cos(angle), math.Sin(angle))
+
+		for start := 0; start < n; start += size {
+			twiddle := complex(1, 0)
+
+			for k := 0; k < size/2; k++ {
+				even := values[start+k]
+				odd := twiddle * values[start+k+size/2]
+				values[start+k] = even + odd
+				values[start+k+size/2] = even - odd
+				twiddle *= step
+			}
+		}
+	}
+}
+
+// isPowerOfTwo reports whether n is a positive power of two
+func isPowerOfTwo(n int) bool {
+	return n > 0 && n&(n-1) == 0
+}
@@ -0,0 +1,68 @@
+package spectrum
+
+import (
+	"math"
+)
+
+// Welch estimates the one-sided power spectral density of evenly spaced
+// values by averaging the periodograms of half-overlapping Hann-windowed
+// segments. Each segment is detrended by its mean. It returns the frequency
+// of each bin in Hz and its density in squared units per Hz.
+func Welch(values []float64, sampleRate float64, segment int) ([]float64, []float64) {
+	if !isPowerOfTwo(segment) || segment < 2 || len(values) < segment {
+		return nil, nil
+	}
+
+	hann := make([]float64, segment)
+	windowPower := 0.0
+
+	for i := range hann {
+		hann[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(segment))
+		windowPower += hann[i] * hann[i]
+	}
+
+	bins := segment/2 + 1
+	density := make([]float64, bins)
+	segments := 0
+	buffer := make([]complex128, segment)
+
+	// Segments end on the newest value so that it is always included
+	for end := len(values); end-segment >= 0; end -= segment / 2 {
+		part := values[end-segment : end]
+		mean := 0.0
+
+		for _, value := range part {
+			mean += value
+		}
+
+		mean /= float64(segment)
+
+		for i, value := range part {
+			buffer[i] = complex((value-mean)*hann[i], 0)
+		}
+
+		fft(buffer)
+
+		for k := 0; k < bins; k++ {
+			re, im := real(buffer[k]), imag(buffer[k])
+			density[k] += re*re + im*im
+		}
+
+		segments++
+	}
+
+	frequencies := make([]float64, bins)
+	scale := 1 / (sampleRate * windowPower * float64(segments))
+
+	for k := range density {
+		frequencies[k] = float64(k) * sampleRate / float64(segment)
+		density[k] *= scale
+
+		// Fold the negative frequencies onto the positive ones
+		if k != 0 && k != segment/2 {
+			density[k] *= 2
+		}
+	}
+
+	return frequencies, density
+}
@@ -0,0 +1,64 @@
+package sqs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iss-telemetry-analyzer/src/pipeline"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// failureRecord is what Lambda sends to an on-failure destination when a
+// Kinesis batch exhausted its retries
+type failureRecord struct {
+	KinesisBatchInfo *kinesisBatchInfo `json:"KinesisBatchInfo"`
+}
+
+type kinesisBatchInfo struct {
+	ShardID             string `json:"shardId"`
+	StartSequenceNumber string `json:"startSequenceNumber"`
+	EndSequenceNumber   string `json:"endSequenceNumber"`
+	StreamArn           string `json:"streamArn"`
+}
+
+// Handler re-drives dead-letter queue items. Kinesis failure records are
+// re-read from the stream and any other message body is ingested as a
+// telemetry payload. Failed messages are reported so only they are retried.
+func Handler(ctx context.Context, sqsEvent events.SQSEvent) (events.SQSEventResponse, error) {
+	var response events.SQSEventResponse
+	var payloadBatch *pipeline.Batch
+
+	for _, message := range sqsEvent.Records {
+		var err error
+		var failure failureRecord
+
+		if json.Unmarshal([]byte(message.Body), &failure) == nil && failure.KinesisBatchInfo != nil {
+			err = redriveKinesisBatch(ctx, *failure.KinesisBatchInfo)
+		} else {
+			if payloadBatch == nil {
+				payloadBatch, err = pipeline.NewBatch(ctx, message.EventSourceARN)
+
+				if err != nil {
+					return response, err
+				}
+			}
+
+			err = payloadBatch.Ingest(ctx, message.MessageId, []byte(message.Body))
+		}
+
+		if err != nil {
+			fmt.Printf("Error re-driving message %s: %v\n", message.MessageId, err)
+
+			response.BatchItemFailures = append(response.BatchItemFailures, events.SQSBatchItemFailure{
+				ItemIdentifier: message.MessageId,
+			})
+		}
+	}
+
+	if payloadBatch != nil {
+		payloadBatch.Finish(ctx)
+	}
+
+	return response, nil
+}
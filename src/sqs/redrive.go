@@ -0,0 +1,87 @@
+package sqs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iss-telemetry-analyzer/src/pipeline"
+	"math/big"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+)
+
+// maxGetRecordsCalls bounds the reads done for a single failed batch
+const maxGetRecordsCalls = 100
+
+// redriveKinesisBatch reads the records of a failed batch back from the
+// stream and ingests them again. Records that were processed before the
+// failure are skipped by the deduplicator.
+func redriveKinesisBatch(ctx context.Context, info kinesisBatchInfo) error {
+	end, ok := new(big.Int).SetString(info.EndSequenceNumber, 10)
+
+	if !ok {
+		return fmt.Errorf("invalid end sequence number %s", info.EndSequenceNumber)
+	}
+
+	sess := session.Must(session.NewSession(&aws.Config{
+		Region: aws.String("eu-west-1"),
+	}))
+	client := kinesis.New(sess)
+
+	iterator, err := client.GetShardIteratorWithContext(ctx, &kinesis.GetShardIteratorInput{
+		StreamARN:              aws.String(info.StreamArn),
+		ShardId:                aws.String(info.ShardID),
+		ShardIteratorType:      aws.String(kinesis.ShardIteratorTypeAtSequenceNumber),
+		StartingSequenceNumber: aws.String(info.StartSequenceNumber),
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to get iterator of %s: %w", info.ShardID, err)
+	}
+
+	batch, err := pipeline.NewBatch(ctx, info.StreamArn)
+
+	if err != nil {
+		return err
+	}
+
+	defer batch.Finish(ctx)
+
+	var errs []error
+	shardIterator := iterator.ShardIterator
+
+	for calls := 0; shardIterator != nil && calls < maxGetRecordsCalls; calls++ {
+		output, err := client.GetRecordsWithContext(ctx, &kinesis.GetRecordsInput{
+			StreamARN:     aws.String(info.StreamArn),
+			ShardIterator: shardIterator,
+		})
+
+		if err != nil {
+			return errors.Join(append(errs, fmt.Errorf("failed to read %s: %w", info.ShardID, err))...)
+		}
+
+		for _, record := range output.Records {
+			sequenceNumber := aws.StringValue(record.SequenceNumber)
+			current, ok := new(big.Int).SetString(sequenceNumber, 10)
+
+			if !ok || current.Cmp(end) > 0 {
+				return errors.Join(errs...)
+			}
+
+			// Same record ID as the Kinesis event ID of the original delivery
+			if err := batch.Ingest(ctx, info.ShardID+":"+sequenceNumber, record.Data); err != nil {
+				errs = append(errs, fmt.Errorf("record %s: %w", sequenceNumber, err))
+			}
+		}
+
+		if len(output.Records) == 0 && aws.Int64Value(output.MillisBehindLatest) == 0 {
+			break
+		}
+
+		shardIterator = output.NextShardIterator
+	}
+
+	return errors.Join(errs...)
+}
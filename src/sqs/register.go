@@ -0,0 +1,17 @@
+package sqs
+
+import (
+	"encoding/json"
+	"iss-telemetry-analyzer/src/router"
+)
+
+func init() {
+	router.Register(router.Source{
+		Name:     "sqs",
+		Priority: 20,
+		Detect: func(event json.RawMessage) bool {
+			return router.RecordsFrom(event, "aws:sqs")
+		},
+		Handle: router.Typed(Handler),
+	})
+}
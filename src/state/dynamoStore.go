@@ -0,0 +1,85 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// DynamoStore keeps sensor state in a DynamoDB table keyed by Channel and
+// uses the Version attribute for optimistic locking.
+type DynamoStore struct {
+	client    *dynamodb.DynamoDB
+	tableName string
+}
+
+func NewDynamoStore(client *dynamodb.DynamoDB, tableName string) *DynamoStore {
+	return &DynamoStore{client: client, tableName: tableName}
+}
+
+func (d *DynamoStore) Get(ctx context.Context, channel string) (*SensorState, error) {
+	result, err := d.client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(d.tableName),
+		ConsistentRead: aws.Bool(true),
+		Key: map[string]*dynamodb.AttributeValue{
+			"Channel": {S: aws.String(channel)},
+		},
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch state of %s: %w", channel, err)
+	}
+
+	sensorState := SensorState{Channel: channel}
+
+	if result.Item != nil {
+		if err := dynamodbattribute.UnmarshalMap(result.Item, &sensorState); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal state of %s: %w", channel, err)
+		}
+	}
+
+	return &sensorState, nil
+}
+
+func (d *DynamoStore) Put(ctx context.Context, sensorState *SensorState) error {
+	expectedVersion := sensorState.Version
+
+	updated := *sensorState
+	updated.Version = expectedVersion + 1
+
+	item, err := dynamodbattribute.MarshalMap(updated)
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal state of %s: %w", sensorState.Channel, err)
+	}
+
+	// Only overwrite the version we read, or create the item if it is new
+	_, err = d.client.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(d.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(Channel) OR Version = :expected"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":expected": {N: aws.String(strconv.FormatInt(expectedVersion, 10))},
+		},
+	})
+
+	var awsErr awserr.Error
+
+	if errors.As(err, &awsErr) && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return ErrConflict
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to store state of %s: %w", sensorState.Channel, err)
+	}
+
+	sensorState.Version = updated.Version
+
+	return nil
+}
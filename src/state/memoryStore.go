@@ -0,0 +1,73 @@
+package state
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryStore keeps sensor state in process memory. It is only shared
+// between invocations of the same warm Lambda instance.
+type MemoryStore struct {
+	mu     sync.Mutex
+	states map[string]SensorState
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: map[string]SensorState{}}
+}
+
+func (m *MemoryStore) Get(ctx context.Context, channel string) (*SensorState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.states[channel]
+
+	if !ok {
+		return &SensorState{Channel: channel}, nil
+	}
+
+	return copyState(stored), nil
+}
+
+func (m *MemoryStore) Put(ctx context.Context, sensorState *SensorState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.states[sensorState.Channel].Version != sensorState.Version {
+		return ErrConflict
+	}
+
+	sensorState.Version++
+	m.states[sensorState.Channel] = *copyState(*sensorState)
+
+	return nil
+}
+
+// copyState detaches the readings and window so callers cannot mutate stored state
+func copyState(sensorState SensorState) *SensorState {
+	if sensorState.Current != nil {
+		current := *sensorState.Current
+		sensorState.Current = &current
+	}
+
+	if sensorState.Previous != nil {
+		previous := *sensorState.Previous
+		sensorState.Previous = &previous
+	}
+
+	if sensorState.SequenceCount != nil {
+		sequenceCount := *sensorState.SequenceCount
+		sensorState.SequenceCount = &sequenceCount
+	}
+
+	if sensorState.Spike != nil {
+		spike := *sensorState.Spike
+		sensorState.Spike = &spike
+	}
+
+	if sensorState.Window != nil {
+		sensorState.Window = sensorState.Window.Clone()
+	}
+
+	return &sensorState
+}
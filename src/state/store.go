@@ -0,0 +1,95 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"iss-telemetry-analyzer/src/dynamo"
+	"iss-telemetry-analyzer/src/window"
+)
+
+// ErrConflict is returned by Put when the stored state was modified by
+// another invocation since it was read.
+var ErrConflict = errors.New("sensor state was modified concurrently")
+
+// maxUpdateAttempts bounds the read-modify-write retries done by Update
+const maxUpdateAttempts = 5
+
+// Reading is a single value of a channel at a point in time
+type Reading struct {
+	Value     float64 `dynamodbav:"Value"`
+	State     string  `dynamodbav:"State,omitempty"` // State of discrete channels, Value is its index
+	Timestamp string  `dynamodbav:"Timestamp"`
+}
+
+// SensorState holds the latest readings of a telemetry channel
+type SensorState struct {
+	Channel  string   `dynamodbav:"Channel"`
+	Current  *Reading `dynamodbav:"Current,omitempty"`
+	Previous *Reading `dynamodbav:"Previous,omitempty"`
+	// Latest reading rejected as a spike, and the time since which the
+	// current value has not changed, kept by the data-quality checks
+	Spike          *Reading `dynamodbav:"Spike,omitempty"`
+	UnchangedSince string   `dynamodbav:"UnchangedSince,omitempty"`
+	// Last sequence count of a CCSDS APID, whose state is keyed by
+	// ccsds.SequenceKey
+	SequenceCount *uint16 `dynamodbav:"SequenceCount,omitempty"`
+	// Bucket values of the latest rolling windows, kept by the finalizer
+	Window  *window.Ring `dynamodbav:"Window,omitempty"`
+	Version int64        `dynamodbav:"Version"`
+}
+
+// Store persists sensor state per channel. Put must only succeed when the
+// stored version still matches state.Version, and increments it on success.
+type Store interface {
+	Get(ctx context.Context, channel string) (*SensorState, error)
+	Put(ctx context.Context, state *SensorState) error
+}
+
+// NewStoreFromEnv builds the store selected by SENSOR_STATE_STORE
+// ("dynamodb" by default, or "memory")
+func NewStoreFromEnv() Store {
+	if os.Getenv("SENSOR_STATE_STORE") == "memory" {
+		return NewMemoryStore()
+	}
+
+	tableName := os.Getenv("SENSOR_STATE_TABLE")
+
+	if tableName == "" {
+		tableName = "SensorState"
+	}
+
+	return NewDynamoStore(dynamo.GetDynamoDBClient(), tableName)
+}
+
+// Update applies fn to the stored state of a channel and writes it back,
+// retrying when a concurrent invocation wins the conditional write.
+func Update(ctx context.Context, store Store, channel string, fn func(*SensorState) error) (*SensorState, error) {
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		sensorState, err := store.Get(ctx, channel)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := fn(sensorState); err != nil {
+			return nil, err
+		}
+
+		err = store.Put(ctx, sensorState)
+
+		if errors.Is(err, ErrConflict) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return sensorState, nil
+	}
+
+	return nil, fmt.Errorf("failed to update state of %s after %d attempts: %w", channel, maxUpdateAttempts, ErrConflict)
+}
@@ -0,0 +1,140 @@
+package timestamp
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is the notation of the timestamps of a channel
+type Format string
+
+const (
+	AUTO        Format = "auto"      // Detected from the value
+	RFC3339     Format = "rfc3339"   // 2025-05-03T14:05:22.1Z
+	ISS_HOURS   Format = "iss_hours" // Decimal hours since the start of the GMT year, as in the ISS Live feed
+	DAY_OF_YEAR Format = "doy"       // GMT day of year, 2025-123/14:05:22.100
+)
+
+// dayOfYearLayout also accepts fractional seconds
+const dayOfYearLayout = "2006-002/15:04:05"
+
+var dayOfYearPattern = regexp.MustCompile(`^\d{4}-\d{3}/\d{2}:\d{2}:\d{2}(\.\d+)?$`)
+
+// hoursPerLeapYear bounds ISS hours, with an hour of slack for late updates
+const hoursPerLeapYear = 366*24 + 1
+
+// ParseFormat returns the format with the given name. An empty name selects
+// detection.
+func ParseFormat(name string) (Format, error) {
+	format := Format(strings.ToLower(name))
+
+	switch format {
+	case "":
+		return AUTO, nil
+	case AUTO, RFC3339, ISS_HOURS, DAY_OF_YEAR:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown timestamp format %q", name)
+	}
+}
+
+// Parse reads a timestamp in the given format. ISS hours carry no year, so
+// the year is chosen to put the time closest to the reference, typically
+// the arrival time, which keeps readings from just before a year rollover
+// in the old year.
+func Parse(value string, format Format, reference time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	if format == AUTO || format == "" {
+		format = Detect(value)
+	}
+
+	switch format {
+	case RFC3339:
+		t, err := time.Parse(time.RFC3339Nano, value)
+
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid RFC3339 timestamp %q: %w", value, err)
+		}
+
+		return t.UTC(), nil
+
+	case DAY_OF_YEAR:
+		if !dayOfYearPattern.MatchString(value) {
+			return time.Time{}, fmt.Errorf("invalid day-of-year timestamp %q", value)
+		}
+
+		t, err := time.Parse(dayOfYearLayout, value)
+
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid day-of-year timestamp %q: %w", value, err)
+		}
+
+		return t.UTC(), nil
+
+	case ISS_HOURS:
+		hours, err := strconv.ParseFloat(value, 64)
+
+		if err != nil || math.IsNaN(hours) || hours < 0 || hours >= hoursPerLeapYear {
+			return time.Time{}, fmt.Errorf("invalid ISS hours timestamp %q", value)
+		}
+
+		return fromYearHours(hours, reference), nil
+
+	default:
+		return time.Time{}, fmt.Errorf("unknown timestamp format %q", format)
+	}
+}
+
+// Detect guesses the format of a timestamp
+func Detect(value string) Format {
+	if dayOfYearPattern.MatchString(value) {
+		return DAY_OF_YEAR
+	}
+
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return ISS_HOURS
+	}
+
+	return RFC3339
+}
+
+// Normalize formats a time as UTC RFC3339 with sub-second precision
+func Normalize(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// fromYearHours places hours since the start of a GMT year in the year
+// around the reference that yields the closest time
+func fromYearHours(hours float64, reference time.Time) time.Time {
+	if reference.IsZero() {
+		reference = time.Now()
+	}
+
+	reference = reference.UTC()
+	offset := time.Duration(math.Round(hours * float64(time.Hour)))
+
+	var closest time.Time
+
+	for _, year := range []int{reference.Year() - 1, reference.Year(), reference.Year() + 1} {
+		candidate := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC).Add(offset)
+
+		if closest.IsZero() || absDuration(candidate.Sub(reference)) < absDuration(closest.Sub(reference)) {
+			closest = candidate
+		}
+	}
+
+	return closest
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+
+	return d
+}
@@ -0,0 +1,217 @@
+package transitions
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"iss-telemetry-analyzer/src/channels"
+	"iss-telemetry-analyzer/src/dynamo"
+	"iss-telemetry-analyzer/src/types"
+	"iss-telemetry-analyzer/src/utils"
+)
+
+// Kinds of transition anomalies
+const (
+	IMPROBABLE_TRANSITION = "IMPROBABLE_TRANSITION"
+	UNEXPECTED_DWELL      = "UNEXPECTED_DWELL"
+	ILLEGAL_TRANSITION    = "ILLEGAL_TRANSITION"
+	ILLEGAL_SEQUENCE      = "ILLEGAL_SEQUENCE"
+)
+
+// Learning thresholds before the learned statistics are trusted
+const (
+	minTransitions = 20 // Transitions out of a state
+	minDwellCount  = 10 // Completed dwells in a state
+)
+
+// Transitions less likely than these are flagged
+const (
+	anomalyProbability = 0.01
+	mediumProbability  = 0.05
+)
+
+// Detector checks the state changes of the discrete channels
+type Detector struct {
+	registry *channels.Registry
+}
+
+func NewDetector(registry *channels.Registry) *Detector {
+	return &Detector{registry: registry}
+}
+
+// Observe walks the states read within a bucket for each discrete channel,
+// in event-time order, and checks every state change against the model of
+// the channel before learning from it. Models are created as needed.
+func (d *Detector) Observe(models map[string]*Model, processedData *types.ProcessedData) []types.TransitionAnomaly {
+	var anomalies []types.TransitionAnomaly
+
+	for i := range d.registry.Channels {
+		channel := &d.registry.Channels[i]
+		channelValue, ok := processedData.Channels[channel.Name]
+
+		if !channel.IsDiscrete() || !ok || len(channelValue.StateReadings) == 0 {
+			continue
+		}
+
+		model, ok := models[channel.Name]
+
+		if !ok {
+			model = newModel()
+			models[channel.Name] = model
+		}
+
+		readings := channelValue.StateReadings
+
+		for j, reading := range readings {
+			// Repeats of the current state only matter to the dwell check,
+			// which the latest reading of the bucket covers
+			if reading.State == model.State && j < len(readings)-1 {
+				continue
+			}
+
+			for _, anomaly := range d.observe(channel, model, reading.State, reading.Time) {
+				anomaly.Channel = channel.Name
+				anomaly.Timestamp = processedData.Timestamp
+				anomalies = append(anomalies, anomaly)
+			}
+		}
+	}
+
+	return anomalies
+}
+
+func (d *Detector) observe(channel *channels.Channel, model *Model, state string, seenAt time.Time) []types.TransitionAnomaly {
+	if model.State == "" {
+		model.enter(state, seenAt.Format(time.RFC3339Nano))
+		return nil
+	}
+
+	enteredAt, err := time.Parse(time.RFC3339Nano, model.EnteredAt)
+
+	if err != nil {
+		enteredAt = seenAt
+	}
+
+	dwellSeconds := math.Max(seenAt.Sub(enteredAt).Seconds(), 0)
+
+	if state == model.State {
+		// A dwell is reported once, as soon as it is known to run long
+		if model.DwellReported {
+			return nil
+		}
+
+		if anomaly, ok := checkDwell(model, dwellSeconds); ok && dwellSeconds > model.Dwell[model.State].Mean {
+			model.DwellReported = true
+			return []types.TransitionAnomaly{anomaly}
+		}
+
+		return nil
+	}
+
+	var anomalies []types.TransitionAnomaly
+
+	// A long dwell reported while it lasted is not reported again
+	if anomaly, ok := checkDwell(model, dwellSeconds); ok && !model.DwellReported {
+		anomalies = append(anomalies, anomaly)
+	}
+
+	if allowed, restricted := channel.AllowedTransitions[model.State]; restricted && !slices.Contains(allowed, state) {
+		anomalies = append(anomalies, types.TransitionAnomaly{
+			Kind:  ILLEGAL_TRANSITION,
+			From:  model.State,
+			To:    state,
+			Level: utils.ANOMALY.String(),
+		})
+	} else if probability, total := model.probability(model.State, state); total >= minTransitions && probability < mediumProbability {
+		level := utils.MEDIUM
+
+		if probability < anomalyProbability {
+			level = utils.ANOMALY
+		}
+
+		anomalies = append(anomalies, types.TransitionAnomaly{
+			Kind:        IMPROBABLE_TRANSITION,
+			From:        model.State,
+			To:          state,
+			Level:       level.String(),
+			Probability: probability,
+		})
+	}
+
+	from := model.State
+	model.learn(state, dwellSeconds)
+	model.enter(state, seenAt.Format(time.RFC3339Nano))
+
+	for _, sequence := range channel.IllegalSequences {
+		if len(sequence) <= len(model.History) && slices.Equal(model.History[len(model.History)-len(sequence):], sequence) {
+			anomalies = append(anomalies, types.TransitionAnomaly{
+				Kind:     ILLEGAL_SEQUENCE,
+				From:     from,
+				To:       state,
+				Level:    utils.ANOMALY.String(),
+				Sequence: sequence,
+			})
+		}
+	}
+
+	return anomalies
+}
+
+// checkDwell compares the time spent in the current state with the learned
+// dwell times, the same way anomaly scores are compared with recent scores
+func checkDwell(model *Model, dwellSeconds float64) (types.TransitionAnomaly, bool) {
+	dwell, ok := model.Dwell[model.State]
+
+	if !ok || dwell.Count < minDwellCount {
+		return types.TransitionAnomaly{}, false
+	}
+
+	// Dwell times vary with the sampling of the channel, so deviations
+	// narrower than a bucket are not trusted
+	deviation := math.Max(dwell.standardDeviation(), dynamo.BucketDuration.Seconds())
+	level := utils.ComputeAnomalyLevel(dwellSeconds, deviation, dwell.Mean)
+
+	if level == utils.NO_ANOMALY {
+		return types.TransitionAnomaly{}, false
+	}
+
+	return types.TransitionAnomaly{
+		Kind:         UNEXPECTED_DWELL,
+		From:         model.State,
+		Level:        level.String(),
+		DwellSeconds: dwellSeconds,
+	}, true
+}
+
+// Level is the highest level of the anomalies
+func Level(anomalies []types.TransitionAnomaly) utils.AnomalyLevel {
+	level := utils.NO_ANOMALY
+
+	for _, anomaly := range anomalies {
+		if anomalyLevel, ok := utils.ParseAnomalyLevel(anomaly.Level); ok {
+			level = utils.MaxLevel(level, anomalyLevel)
+		}
+	}
+
+	return level
+}
+
+// Emit logs a transition anomaly
+func Emit(anomaly types.TransitionAnomaly) {
+	logData := map[string]interface{}{
+		"log_type": "transition_anomaly",
+		"anomaly":  anomaly,
+	}
+
+	logDataBytes, err := json.Marshal(logData)
+
+	if err != nil {
+		fmt.Printf("Error marshaling transition anomaly: %v\n", err)
+		return
+	}
+
+	fmt.Println(string(logDataBytes))
+}
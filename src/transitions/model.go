@@ -0,0 +1,95 @@
+package transitions
+
+import (
+	"math"
+
+	"iss-telemetry-analyzer/src/channels"
+)
+
+// maxHistory bounds the states remembered to match illegal sequences
+const maxHistory = channels.MaxSequenceLength
+
+// Model holds what was learned about the states of one channel. It is
+// stored with the bucket cursor and updated as buckets are finalized.
+type Model struct {
+	Counts    map[string]map[string]int `dynamodbav:"Counts"` // Transitions seen, by previous and next state
+	Dwell     map[string]*DwellStats    `dynamodbav:"Dwell"`  // Time spent in each state before leaving it
+	State     string                    `dynamodbav:"State"`
+	EnteredAt string                    `dynamodbav:"EnteredAt"` // Time the current state was first seen
+	History   []string                  `dynamodbav:"History"`   // Latest states, oldest first
+	// Whether an unexpected dwell was reported since the current state was
+	// entered, so that one dwell is reported once
+	DwellReported bool `dynamodbav:"DwellReported"`
+}
+
+// DwellStats keeps a running mean and variance of dwell times in seconds
+type DwellStats struct {
+	Count int     `dynamodbav:"Count"`
+	Mean  float64 `dynamodbav:"Mean"`
+	M2    float64 `dynamodbav:"M2"` // Sum of squared deviations from the mean
+}
+
+func newModel() *Model {
+	return &Model{Counts: map[string]map[string]int{}, Dwell: map[string]*DwellStats{}}
+}
+
+// probability estimates how likely the model is to move from one state to
+// another, and how many transitions out of the state it has seen
+func (m *Model) probability(from string, to string) (float64, int) {
+	total := 0
+
+	for _, count := range m.Counts[from] {
+		total += count
+	}
+
+	if total == 0 {
+		return 0, 0
+	}
+
+	return float64(m.Counts[from][to]) / float64(total), total
+}
+
+// learn records a change to the given state
+func (m *Model) learn(to string, dwellSeconds float64) {
+	if m.Counts[m.State] == nil {
+		m.Counts[m.State] = map[string]int{}
+	}
+
+	m.Counts[m.State][to]++
+
+	dwell, ok := m.Dwell[m.State]
+
+	if !ok {
+		dwell = &DwellStats{}
+		m.Dwell[m.State] = dwell
+	}
+
+	dwell.add(dwellSeconds)
+}
+
+// enter makes the given state the current one
+func (m *Model) enter(state string, at string) {
+	m.State = state
+	m.EnteredAt = at
+	m.DwellReported = false
+	m.History = append(m.History, state)
+
+	if len(m.History) > maxHistory {
+		m.History = m.History[len(m.History)-maxHistory:]
+	}
+}
+
+func (d *DwellStats) add(seconds float64) {
+	d.Count++
+	delta := seconds - d.Mean
+	d.Mean += delta / float64(d.Count)
+	d.M2 += delta * (seconds - d.Mean)
+}
+
+func (d *DwellStats) standardDeviation() float64 {
+	if d.Count < 2 {
+		return 0
+	}
+
+	return math.Sqrt(d.M2 / float64(d.Count-1))
+}
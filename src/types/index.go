@@ -1,9 +1,36 @@
 package types
 
+import "time"
+
 type TelemetryData struct {
 	Name      string `json:"name"`
 	Value     string `json:"value"`
 	Timestamp string `json:"timestamp"`
+
+	// Metadata added from the telemetry dictionary
+	PUI         string `json:"pui,omitempty"`
+	Description string `json:"description,omitempty"`
+	Subsystem   string `json:"subsystem,omitempty"`
+	Unit        string `json:"unit,omitempty"`
+	Label       string `json:"label,omitempty"` // State label of enumerated values
+
+	// Value and unit as reported, when the value was calibrated
+	RawValue string `json:"raw_value,omitempty"`
+	RawUnit  string `json:"raw_unit,omitempty"`
+
+	// Outcome of the data-quality checks, set before buffering
+	Quality string `json:"quality,omitempty"`
+
+	// Packet the reading was decoded from, never stored
+	Packet *PacketSequence `json:"-"`
+}
+
+// PacketSequence identifies a CCSDS space packet by its APID, sequence count
+// and time
+type PacketSequence struct {
+	APID          uint16
+	SequenceCount uint16
+	Time          string
 }
 
 type StoreAnomalyScoreResult struct {
@@ -16,16 +43,105 @@ type StoreAnomalyScoreResult struct {
 type DynamoData struct {
 	BucketKey *string         `dynamodbav:"BucketKey"`
 	Data      []TelemetryData `dynamodbav:"Data"`
+	Finalized bool            `dynamodbav:"Finalized,omitempty"`
+	// Set once the finalized bucket was scored and recorded. Until then the
+	// finalizing invocation holds it until ScoringUntil (epoch seconds).
+	Scored       bool  `dynamodbav:"Scored,omitempty"`
+	ScoringUntil int64 `dynamodbav:"ScoringUntil,omitempty"`
+}
+
+type ChannelValue struct {
+	Value      float64 `json:"value"` // Mean over the bucket
+	ChangeRate float64 `json:"change_rate"`
+	Min        float64 `json:"min"`
+	Max        float64 `json:"max"`
+	Last       float64 `json:"last"`
+	Count      int     `json:"count"` // 0 when carried over from the previous bucket
+	Unit       string  `json:"unit,omitempty"`
+	// Latest state and state counts of discrete channels, whose values
+	// are state indexes
+	State       string         `json:"state,omitempty"`
+	StateCounts map[string]int `json:"state_counts,omitempty"`
+	// States read within the bucket in event-time order, only kept while
+	// the bucket is finalized
+	StateReadings []StateReading `json:"-"`
+	// Resampling grid points without a reading close enough to interpolate
+	// from, and whether that was every point of the bucket
+	MissingPoints int    `json:"missing_points,omitempty"`
+	Missing       bool   `json:"missing,omitempty"`
+	LastSeen      string `json:"last_seen"` // Timestamp of the latest reading
+	Stale         bool   `json:"stale,omitempty"`
+	// Rolling-window statistics of numeric channels, e.g. mean_5m
+	Windows map[string]float64 `json:"windows,omitempty"`
+	// Spectral features of numeric channels, e.g. dominant_frequency
+	Spectrum map[string]float64 `json:"spectrum,omitempty"`
+}
+
+// StateReading is one reading of a discrete channel
+type StateReading struct {
+	State string
+	Time  time.Time
 }
 
 type ProcessedData struct {
-	Timestamp       string  `json:"timestamp"` // Start of 5s bucket
-	FLOWRATE        float64 `json:"flowrate"`
-	PRESSURE        float64 `json:"pressure"`
-	TEMPERATURE     float64 `json:"temperature"`
-	FlowChangeRate  float64 `json:"flow_change_rate"`
-	PressChangeRate float64 `json:"press_change_rate"`
-	TempChangeRate  float64 `json:"temp_change_rate"`
-	AnomalyScore    float64 `json:"anomaly_score"`
-	AnomalyLevel    string  `json:"anomaly_level"`
+	Timestamp    string                  `json:"timestamp"` // Start of 5s bucket
+	Channels     map[string]ChannelValue `json:"channels"`
+	AnomalyScore float64                 `json:"anomaly_score"`
+	AnomalyLevel string                  `json:"anomaly_level"`
+	Scored       bool                    `json:"scored"` // False when inputs were missing or stale
+	// State changes of discrete channels that raised the anomaly level
+	TransitionAnomalies []TransitionAnomaly `json:"transition_anomalies,omitempty"`
+	// Correlations of coupled channels, by coupling
+	Couplings map[string]CouplingValue `json:"couplings,omitempty"`
+	// Couplings that weakened enough to raise the anomaly level
+	DecouplingAnomalies []DecouplingAnomaly `json:"decoupling_anomalies,omitempty"`
+}
+
+// CouplingValue is the correlation of two coupled channels over the window
+// ending with a bucket
+type CouplingValue struct {
+	Channels         []string `json:"channels"`
+	Pearson          float64  `json:"pearson"`
+	CrossCorrelation float64  `json:"cross_correlation"` // Strongest correlation at any lag
+	Lag              float64  `json:"lag"`               // Lag of the strongest correlation in seconds
+	Pairs            int      `json:"pairs"`             // Bucket values correlated
+}
+
+// DecouplingAnomaly is a coupling that is much weaker than it used to be
+type DecouplingAnomaly struct {
+	Coupling    string  `json:"coupling"`
+	Timestamp   string  `json:"timestamp"`   // Start of the bucket where it was detected
+	Correlation float64 `json:"correlation"` // Best-lag correlation of the bucket
+	Expected    float64 `json:"expected"`    // Learned mean strength of the coupling
+	Level       string  `json:"level"`
+}
+
+// TransitionAnomaly is an unusual state change of a discrete channel
+type TransitionAnomaly struct {
+	Channel      string   `json:"channel"`
+	Timestamp    string   `json:"timestamp"` // Start of the bucket where it was detected
+	Kind         string   `json:"kind"`
+	From         string   `json:"from"`
+	To           string   `json:"to,omitempty"`
+	Level        string   `json:"level"`
+	Probability  float64  `json:"probability,omitempty"`   // Learned probability of the transition
+	DwellSeconds float64  `json:"dwell_seconds,omitempty"` // Time spent in the previous state
+	Sequence     []string `json:"sequence,omitempty"`      // Illegal sequence that was completed
+}
+
+type AnomalyEvent struct {
+	Timestamp     string  `json:"timestamp"` // Start of 5s bucket
+	Level         string  `json:"level"`
+	PreviousLevel string  `json:"previous_level"`
+	AnomalyScore  float64 `json:"anomaly_score"`
+
+	TransitionAnomalies []TransitionAnomaly `json:"transition_anomalies,omitempty"`
+	DecouplingAnomalies []DecouplingAnomaly `json:"decoupling_anomalies,omitempty"`
+}
+
+type ScoreStats struct {
+	Count             int     `json:"count"`
+	Latest            float64 `json:"latest_score"`
+	Average           float64 `json:"average"`
+	StandardDeviation float64 `json:"standard_deviation"`
 }
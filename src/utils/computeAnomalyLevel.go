@@ -23,6 +23,40 @@ func (a AnomalyLevel) String() string {
 	}
 }
 
+// Rank orders the levels by severity, with unknown levels ranked lowest
+func (a AnomalyLevel) Rank() int {
+	switch a {
+	case NO_ANOMALY:
+		return 1
+	case MEDIUM:
+		return 2
+	case ANOMALY:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// ParseAnomalyLevel returns the level with the given name
+func ParseAnomalyLevel(level string) (AnomalyLevel, bool) {
+	anomalyLevel := AnomalyLevel(level)
+
+	return anomalyLevel, anomalyLevel.Rank() > 0
+}
+
+// MaxLevel returns the most severe of the levels
+func MaxLevel(levels ...AnomalyLevel) AnomalyLevel {
+	var highest AnomalyLevel
+
+	for _, level := range levels {
+		if level.Rank() > highest.Rank() {
+			highest = level
+		}
+	}
+
+	return highest
+}
+
 func ComputeAnomalyLevel(anomalyScore, stdScore, avgScore float64) AnomalyLevel {
 
 	scoreDeviation := math.Abs(anomalyScore - avgScore)
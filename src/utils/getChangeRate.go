@@ -2,6 +2,8 @@ package utils
 
 import "fmt"
 
+// GetChangeRate returns the two-point change rate per second from the
+// previous to the current value
 func GetChangeRate(currentValue float64, previousValue float64, currentTimestamp string, previousTimestamp string) float64 {
 
 	if previousTimestamp == "" {
@@ -9,7 +11,8 @@ func GetChangeRate(currentValue float64, previousValue float64, currentTimestamp
 	}
 
 	valueDiff := (currentValue - previousValue)
-	timeDiff, err := GetTimeDiff(currentTimestamp, previousTimestamp)
+	// Elapsed time from the previous to the current value
+	timeDiff, err := GetTimeDiff(previousTimestamp, currentTimestamp)
 
 	if err != nil {
 		fmt.Printf("Error calculating time diff between: %s and %s: %v\n", currentTimestamp, previousTimestamp, err)
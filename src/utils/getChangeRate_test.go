@@ -0,0 +1,18 @@
+package utils
+
+import "testing"
+
+func TestGetChangeRateSign(t *testing.T) {
+	// The change rate goes from the previous to the current value
+	rate := GetChangeRate(12, 10, "2024-01-01T00:00:10Z", "2024-01-01T00:00:05Z")
+
+	if rate != 0.4 {
+		t.Errorf("rising from 10 to 12 over 5s has rate %v, want 0.4", rate)
+	}
+
+	rate = GetChangeRate(10, 12, "2024-01-01T00:00:10Z", "2024-01-01T00:00:05Z")
+
+	if rate != -0.4 {
+		t.Errorf("falling from 12 to 10 over 5s has rate %v, want -0.4", rate)
+	}
+}
@@ -3,18 +3,23 @@ package utils
 import (
 	"fmt"
 	"time"
+
+	"iss-telemetry-analyzer/src/timestamp"
 )
 
-// GetTimeDiff calculates the difference in seconds between two ISO 8601 timestamps.
+// GetTimeDiff calculates the seconds elapsed from timestamp1 to timestamp2,
+// in any supported format. The result is negative when timestamp2 is the
+// earlier one. Timestamps without a year are placed relative to each other
+// so that a year rollover does not produce a huge delta.
 func GetTimeDiff(timestamp1, timestamp2 string) (float64, error) {
 	// Parse the first timestamp
-	t1, err := time.Parse(time.RFC3339, timestamp1)
+	t1, err := timestamp.Parse(timestamp1, timestamp.AUTO, time.Now())
 	if err != nil {
 		return 0, fmt.Errorf("error parsing timestamp1: %v", err)
 	}
 
 	// Parse the second timestamp
-	t2, err := time.Parse(time.RFC3339, timestamp2)
+	t2, err := timestamp.Parse(timestamp2, timestamp.AUTO, t1)
 	if err != nil {
 		return 0, fmt.Errorf("error parsing timestamp2: %v", err)
 	}
@@ -0,0 +1,151 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"iss-telemetry-analyzer/src/dynamo"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+const connectionsTable = "WebSocketConnections"
+
+// Connection is a dashboard subscribed to live anomaly scores
+type Connection struct {
+	ConnectionID string   `dynamodbav:"ConnectionId"`
+	Endpoint     string   `dynamodbav:"Endpoint"` // Management API endpoint of the stage
+	Channels     []string `dynamodbav:"Channels,omitempty"`
+	MinLevel     string   `dynamodbav:"MinLevel,omitempty"`
+	ConnectedAt  string   `dynamodbav:"ConnectedAt"`
+}
+
+func saveConnection(ctx context.Context, connection Connection) error {
+	client := dynamo.GetDynamoDBClient()
+
+	item, err := dynamodbattribute.MarshalMap(connection)
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal connection: %w", err)
+	}
+
+	_, err = client.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(connectionsTable),
+		Item:      item,
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to store connection %s: %w", connection.ConnectionID, err)
+	}
+
+	return nil
+}
+
+func deleteConnection(ctx context.Context, connectionID string) error {
+	client := dynamo.GetDynamoDBClient()
+
+	_, err := client.DeleteItemWithContext(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(connectionsTable),
+		Key: map[string]*dynamodb.AttributeValue{
+			"ConnectionId": {S: aws.String(connectionID)},
+		},
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to delete connection %s: %w", connectionID, err)
+	}
+
+	return nil
+}
+
+// updateSubscription replaces the filters of an existing connection
+func updateSubscription(ctx context.Context, connectionID string, channels []string, minLevel string) error {
+	client := dynamo.GetDynamoDBClient()
+
+	channelsAttr, err := dynamodbattribute.Marshal(channels)
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal channels: %w", err)
+	}
+
+	_, err = client.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(connectionsTable),
+		Key: map[string]*dynamodb.AttributeValue{
+			"ConnectionId": {S: aws.String(connectionID)},
+		},
+		UpdateExpression:    aws.String("SET Channels = :channels, MinLevel = :minLevel, SubscribedAt = :now"),
+		ConditionExpression: aws.String("attribute_exists(ConnectionId)"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":channels": channelsAttr,
+			":minLevel": {S: aws.String(minLevel)},
+			":now":      {S: aws.String(time.Now().UTC().Format(time.RFC3339))},
+		},
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to update subscription of %s: %w", connectionID, err)
+	}
+
+	return nil
+}
+
+func listConnections(ctx context.Context) ([]Connection, error) {
+	client := dynamo.GetDynamoDBClient()
+
+	var connections []Connection
+
+	err := client.ScanPagesWithContext(ctx, &dynamodb.ScanInput{
+		TableName: aws.String(connectionsTable),
+	}, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		var pageConnections []Connection
+
+		if err := dynamodbattribute.UnmarshalListOfMaps(page.Items, &pageConnections); err != nil {
+			fmt.Printf("Failed to unmarshal connections: %v\n", err)
+			return true
+		}
+
+		connections = append(connections, pageConnections...)
+
+		return true
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list connections: %w", err)
+	}
+
+	return connections, nil
+}
+
+// maxConnectionAge is the longest API Gateway keeps a WebSocket open
+const maxConnectionAge = 2 * time.Hour
+
+// PruneConnections deletes connections API Gateway has closed without
+// sending $disconnect
+func PruneConnections(ctx context.Context, now time.Time) (int, error) {
+	connections, err := listConnections(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+
+	pruned := 0
+
+	for _, connection := range connections {
+		connectedAt, err := time.Parse(time.RFC3339, connection.ConnectedAt)
+
+		if err == nil && now.Sub(connectedAt) <= maxConnectionAge {
+			continue
+		}
+
+		if err := deleteConnection(ctx, connection.ConnectionID); err != nil {
+			return pruned, err
+		}
+
+		pruned++
+	}
+
+	return pruned, nil
+}
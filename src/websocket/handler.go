@@ -0,0 +1,91 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"iss-telemetry-analyzer/src/utils"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// subscribeMessage is sent by clients to choose what they receive
+type subscribeMessage struct {
+	Action   string   `json:"action"`
+	Channels []string `json:"channels"`  // Empty for every channel
+	MinLevel string   `json:"min_level"` // Empty for every level
+}
+
+// Handler manages the connections of the API Gateway WebSocket API
+func Handler(ctx context.Context, request events.APIGatewayWebsocketProxyRequest) (events.APIGatewayProxyResponse, error) {
+	connectionID := request.RequestContext.ConnectionID
+
+	switch request.RequestContext.RouteKey {
+	case "$connect":
+		err := saveConnection(ctx, Connection{
+			ConnectionID: connectionID,
+			Endpoint:     managementEndpoint(request.RequestContext),
+			ConnectedAt:  time.Now().UTC().Format(time.RFC3339),
+		})
+
+		if err != nil {
+			fmt.Printf("Error registering connection: %v\n", err)
+			return response(http.StatusInternalServerError, "could not register connection"), nil
+		}
+
+		return response(http.StatusOK, "connected"), nil
+
+	case "$disconnect":
+		if err := deleteConnection(ctx, connectionID); err != nil {
+			fmt.Printf("Error removing connection: %v\n", err)
+		}
+
+		return response(http.StatusOK, "disconnected"), nil
+
+	default:
+		return handleMessage(ctx, connectionID, request.Body), nil
+	}
+}
+
+func handleMessage(ctx context.Context, connectionID string, body string) events.APIGatewayProxyResponse {
+	var message subscribeMessage
+
+	if err := json.Unmarshal([]byte(body), &message); err != nil {
+		return response(http.StatusBadRequest, "invalid message")
+	}
+
+	if message.Action != "subscribe" {
+		return response(http.StatusBadRequest, fmt.Sprintf("unknown action %q", message.Action))
+	}
+
+	if message.MinLevel != "" {
+		if _, ok := utils.ParseAnomalyLevel(message.MinLevel); !ok {
+			return response(http.StatusBadRequest, fmt.Sprintf("unknown anomaly level %q", message.MinLevel))
+		}
+	}
+
+	if err := updateSubscription(ctx, connectionID, message.Channels, message.MinLevel); err != nil {
+		fmt.Printf("Error updating subscription: %v\n", err)
+		return response(http.StatusInternalServerError, "could not update subscription")
+	}
+
+	return response(http.StatusOK, "subscribed")
+}
+
+// managementEndpoint is where messages to the connection are posted.
+// WEBSOCKET_ENDPOINT overrides it when the API uses a custom domain.
+func managementEndpoint(requestContext events.APIGatewayWebsocketProxyRequestContext) string {
+	if endpoint := os.Getenv("WEBSOCKET_ENDPOINT"); endpoint != "" {
+		return endpoint
+	}
+
+	return "https://" + requestContext.DomainName + "/" + requestContext.Stage
+}
+
+func response(statusCode int, body string) events.APIGatewayProxyResponse {
+	return events.APIGatewayProxyResponse{StatusCode: statusCode, Body: body}
+}
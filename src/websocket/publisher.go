@@ -0,0 +1,210 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"iss-telemetry-analyzer/src/types"
+	"iss-telemetry-analyzer/src/utils"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/apigatewaymanagementapi"
+)
+
+// levelChange is pushed when the anomaly level of consecutive scores differs
+type levelChange struct {
+	Timestamp     string `json:"timestamp"`
+	PreviousLevel string `json:"previous_level"`
+	Level         string `json:"level"`
+}
+
+type message struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// Publisher pushes scored data to the subscribed WebSocket connections. The
+// connections are listed on first publish and kept until Refresh.
+type Publisher struct {
+	mu          sync.Mutex
+	clients     map[string]*apigatewaymanagementapi.ApiGatewayManagementApi
+	connections []Connection
+	listed      bool
+}
+
+func NewPublisher() *Publisher {
+	return &Publisher{clients: map[string]*apigatewaymanagementapi.ApiGatewayManagementApi{}}
+}
+
+// Refresh forgets the listed connections, so that the next publish lists
+// them again
+func (p *Publisher) Refresh() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.connections = nil
+	p.listed = false
+}
+
+// subscribers returns the listed connections, listing them when needed
+func (p *Publisher) subscribers(ctx context.Context) ([]Connection, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.listed {
+		return p.connections, nil
+	}
+
+	connections, err := listConnections(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p.connections = connections
+	p.listed = true
+
+	return connections, nil
+}
+
+// forget drops a closed connection from the listed ones
+func (p *Publisher) forget(connectionID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.connections = slices.DeleteFunc(slices.Clone(p.connections), func(connection Connection) bool {
+		return connection.ConnectionID == connectionID
+	})
+}
+
+// Publish sends the processed data to every subscriber whose filters match,
+// followed by an anomaly-level change when the level differs from the
+// previous score
+func (p *Publisher) Publish(ctx context.Context, processedData types.ProcessedData, previousLevel string) error {
+	connections, err := p.subscribers(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	level, _ := utils.ParseAnomalyLevel(processedData.AnomalyLevel)
+	previous, _ := utils.ParseAnomalyLevel(previousLevel)
+	changed := previousLevel != "" && previous != level
+
+	var errs []error
+
+	for _, connection := range connections {
+		minLevel, _ := utils.ParseAnomalyLevel(connection.MinLevel)
+
+		if filtered, ok := filterChannels(processedData, connection.Channels); ok && level.Rank() >= minLevel.Rank() {
+			errs = append(errs, p.send(ctx, connection, message{Type: "processed_data", Data: filtered}))
+		}
+
+		// Changes are sent when either side reaches the level of interest
+		if changed && max(level.Rank(), previous.Rank()) >= minLevel.Rank() {
+			errs = append(errs, p.send(ctx, connection, message{Type: "anomaly_level_change", Data: levelChange{
+				Timestamp:     processedData.Timestamp,
+				PreviousLevel: previousLevel,
+				Level:         processedData.AnomalyLevel,
+			}}))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// filterChannels keeps the subscribed channels, with the couplings and
+// anomalies involving them, and reports false when the data has none of them
+func filterChannels(processedData types.ProcessedData, channels []string) (types.ProcessedData, bool) {
+	if len(channels) == 0 {
+		return processedData, true
+	}
+
+	filtered := processedData
+	filtered.Channels = map[string]types.ChannelValue{}
+	filtered.Couplings = nil
+	filtered.TransitionAnomalies = nil
+	filtered.DecouplingAnomalies = nil
+
+	for name, channelValue := range processedData.Channels {
+		if slices.Contains(channels, name) {
+			filtered.Channels[name] = channelValue
+		}
+	}
+
+	// Couplings are kept when either of their channels is subscribed
+	for name, couplingValue := range processedData.Couplings {
+		if slices.ContainsFunc(couplingValue.Channels, func(channel string) bool { return slices.Contains(channels, channel) }) {
+			if filtered.Couplings == nil {
+				filtered.Couplings = map[string]types.CouplingValue{}
+			}
+
+			filtered.Couplings[name] = couplingValue
+		}
+	}
+
+	for _, anomaly := range processedData.TransitionAnomalies {
+		if slices.Contains(channels, anomaly.Channel) {
+			filtered.TransitionAnomalies = append(filtered.TransitionAnomalies, anomaly)
+		}
+	}
+
+	for _, anomaly := range processedData.DecouplingAnomalies {
+		if _, ok := filtered.Couplings[anomaly.Coupling]; ok {
+			filtered.DecouplingAnomalies = append(filtered.DecouplingAnomalies, anomaly)
+		}
+	}
+
+	return filtered, len(filtered.Channels) > 0
+}
+
+func (p *Publisher) send(ctx context.Context, connection Connection, payload message) error {
+	data, err := json.Marshal(payload)
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s message: %w", payload.Type, err)
+	}
+
+	_, err = p.client(connection.Endpoint).PostToConnectionWithContext(ctx, &apigatewaymanagementapi.PostToConnectionInput{
+		ConnectionId: aws.String(connection.ConnectionID),
+		Data:         data,
+	})
+
+	var awsErr awserr.Error
+
+	if errors.As(err, &awsErr) && awsErr.Code() == apigatewaymanagementapi.ErrCodeGoneException {
+		// The client went away without a $disconnect
+		p.forget(connection.ConnectionID)
+		return deleteConnection(ctx, connection.ConnectionID)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to push to connection %s: %w", connection.ConnectionID, err)
+	}
+
+	return nil
+}
+
+func (p *Publisher) client(endpoint string) *apigatewaymanagementapi.ApiGatewayManagementApi {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if client, ok := p.clients[endpoint]; ok {
+		return client
+	}
+
+	sess := session.Must(session.NewSession(&aws.Config{
+		Region:   aws.String("eu-west-1"),
+		Endpoint: aws.String(endpoint),
+	}))
+	client := apigatewaymanagementapi.New(sess)
+	p.clients[endpoint] = client
+
+	return client
+}
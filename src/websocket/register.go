@@ -0,0 +1,22 @@
+package websocket
+
+import (
+	"encoding/json"
+	"iss-telemetry-analyzer/src/router"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func init() {
+	router.Register(router.Source{
+		Name: "websocket",
+		// Checked before plain HTTP requests, which also have a request context
+		Priority: 40,
+		Detect: func(event json.RawMessage) bool {
+			var websocketEvent events.APIGatewayWebsocketProxyRequest
+
+			return json.Unmarshal(event, &websocketEvent) == nil && websocketEvent.RequestContext.EventType != ""
+		},
+		Handle: router.Typed(Handler),
+	})
+}
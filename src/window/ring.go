@@ -0,0 +1,92 @@
+package window
+
+// Point is the value of a channel over one bucket. Attribute names are kept
+// short as a ring of one orbit holds over a thousand points.
+type Point struct {
+	Time  int64   `dynamodbav:"T"` // Start of the bucket in Unix seconds
+	Value float64 `dynamodbav:"V"`
+}
+
+// Ring keeps the newest points of a channel, overwriting the oldest point
+// once it is full
+type Ring struct {
+	Capacity int     `dynamodbav:"Capacity"`
+	Next     int     `dynamodbav:"Next"` // Slot overwritten by the next push once full
+	Points   []Point `dynamodbav:"Points"`
+}
+
+// NewRing creates an empty ring holding up to capacity points
+func NewRing(capacity int) *Ring {
+	return &Ring{Capacity: capacity}
+}
+
+// Push adds a point newer than every point of the ring. Older points are
+// ignored so that a bucket is never counted twice.
+func (r *Ring) Push(point Point) {
+	if r.Capacity <= 0 {
+		return
+	}
+
+	if newest, ok := r.Newest(); ok && point.Time <= newest.Time {
+		return
+	}
+
+	if len(r.Points) < r.Capacity {
+		r.Points = append(r.Points, point)
+		return
+	}
+
+	r.Points[r.Next] = point
+	r.Next = (r.Next + 1) % r.Capacity
+}
+
+// Newest returns the latest point of the ring
+func (r *Ring) Newest() (Point, bool) {
+	if len(r.Points) == 0 {
+		return Point{}, false
+	}
+
+	if len(r.Points) < r.Capacity || r.Next == 0 {
+		return r.Points[len(r.Points)-1], true
+	}
+
+	return r.Points[r.Next-1], true
+}
+
+// Ordered returns the points of the ring, oldest first
+func (r *Ring) Ordered() []Point {
+	ordered := make([]Point, 0, len(r.Points))
+
+	if len(r.Points) < r.Capacity {
+		return append(ordered, r.Points...)
+	}
+
+	ordered = append(ordered, r.Points[r.Next:]...)
+
+	return append(ordered, r.Points[:r.Next]...)
+}
+
+// Resize changes the capacity of the ring, keeping its newest points
+func (r *Ring) Resize(capacity int) {
+	if capacity == r.Capacity {
+		return
+	}
+
+	points := r.Ordered()
+
+	if len(points) > capacity {
+		points = points[len(points)-capacity:]
+	}
+
+	r.Capacity = capacity
+	r.Next = 0
+	r.Points = points
+}
+
+// Clone returns a copy of the ring that shares no points with it
+func (r *Ring) Clone() *Ring {
+	clone := *r
+	clone.Points = append([]Point(nil), r.Points...)
+
+	return &clone
+}
@@ -0,0 +1,195 @@
+package window
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Statistic is a summary of the points of a channel within a horizon
+type Statistic string
+
+const (
+	MEAN           Statistic = "mean"
+	STD            Statistic = "std" // Population standard deviation
+	MIN            Statistic = "min"
+	MAX            Statistic = "max"
+	RANGE          Statistic = "range"
+	SLOPE          Statistic = "slope"          // Least-squares slope, per second
+	ZERO_CROSSINGS Statistic = "zero_crossings" // Crossings of the mean of the horizon
+)
+
+// Statistics lists every statistic in the order features are laid out
+var Statistics = []Statistic{MEAN, STD, MIN, MAX, RANGE, SLOPE, ZERO_CROSSINGS}
+
+// OrbitPeriod is the orbital period of the ISS, about 92.7 minutes
+const OrbitPeriod = 5561 * time.Second
+
+// Horizon is a rolling window over the newest points of a channel
+type Horizon struct {
+	Name       string
+	Length     time.Duration
+	Statistics []Statistic
+}
+
+// ParseStatistic reads a statistic name, ignoring case
+func ParseStatistic(name string) (Statistic, error) {
+	statistic := Statistic(strings.ToLower(name))
+
+	for _, known := range Statistics {
+		if statistic == known {
+			return statistic, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown window statistic %q", name)
+}
+
+// Key names a statistic over a horizon, e.g. mean_5m
+func Key(statistic Statistic, horizon string) string {
+	return string(statistic) + "_" + horizon
+}
+
+// Compute summarises the points within each horizon of the newest point.
+// Points must be in time order. The result is keyed by Key.
+func Compute(points []Point, horizons []Horizon) map[string]float64 {
+	if len(points) == 0 {
+		return nil
+	}
+
+	newest := points[len(points)-1].Time
+	values := map[string]float64{}
+
+	for _, horizon := range horizons {
+		start := newest - int64(horizon.Length/time.Second)
+		first := len(points) - 1
+
+		for first > 0 && points[first-1].Time > start {
+			first--
+		}
+
+		within := points[first:]
+
+		for _, statistic := range horizon.Statistics {
+			values[Key(statistic, horizon.Name)] = compute(within, statistic)
+		}
+	}
+
+	return values
+}
+
+func compute(points []Point, statistic Statistic) float64 {
+	switch statistic {
+	case MEAN:
+		return mean(points)
+	case STD:
+		return std(points)
+	case MIN:
+		return minimum(points)
+	case MAX:
+		return maximum(points)
+	case RANGE:
+		return maximum(points) - minimum(points)
+	case SLOPE:
+		return slope(points)
+	case ZERO_CROSSINGS:
+		return zeroCrossings(points)
+	default:
+		return 0
+	}
+}
+
+func mean(points []Point) float64 {
+	sum := 0.0
+
+	for _, point := range points {
+		sum += point.Value
+	}
+
+	return sum / float64(len(points))
+}
+
+func std(points []Point) float64 {
+	average := mean(points)
+	sum := 0.0
+
+	for _, point := range points {
+		sum += (point.Value - average) * (point.Value - average)
+	}
+
+	return math.Sqrt(sum / float64(len(points)))
+}
+
+func minimum(points []Point) float64 {
+	value := math.Inf(1)
+
+	for _, point := range points {
+		value = math.Min(value, point.Value)
+	}
+
+	return value
+}
+
+func maximum(points []Point) float64 {
+	value := math.Inf(-1)
+
+	for _, point := range points {
+		value = math.Max(value, point.Value)
+	}
+
+	return value
+}
+
+// slope fits a line through the points, which is flat for a single point
+func slope(points []Point) float64 {
+	if len(points) < 2 {
+		return 0
+	}
+
+	// Times are taken relative to the first point to keep the sums small
+	var sumT, sumV, sumTT, sumTV float64
+
+	for _, point := range points {
+		t := float64(point.Time - points[0].Time)
+		sumT += t
+		sumV += point.Value
+		sumTT += t * t
+		sumTV += t * point.Value
+	}
+
+	n := float64(len(points))
+	denominator := n*sumTT - sumT*sumT
+
+	if denominator == 0 {
+		return 0
+	}
+
+	return (n*sumTV - sumT*sumV) / denominator
+}
+
+// zeroCrossings counts how often the points cross their mean. Points equal
+// to the mean do not end a crossing.
+func zeroCrossings(points []Point) float64 {
+	average := mean(points)
+	crossings := 0
+	sign := 0.0
+
+	for _, point := range points {
+		deviation := point.Value - average
+
+		if deviation == 0 {
+			continue
+		}
+
+		current := math.Copysign(1, deviation)
+
+		if sign != 0 && current != sign {
+			crossings++
+		}
+
+		sign = current
+	}
+
+	return float64(crossings)
+}